@@ -1,25 +1,54 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"go/token"
 	"io/ioutil"
 	"jsson/internal/lexer"
 	"jsson/internal/parser"
 	"jsson/internal/transpiler"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+// stringList collects a repeatable string flag such as -I
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
 func main() {
+	var includeDirs stringList
+	flag.Var(&includeDirs, "I", "Add a directory to the include search path (repeatable)")
 	inputPtr := flag.String("i", "", "Input JSSON file")
-	formatPtr := flag.String("f", "json", "Output format: json|yaml|toml")
+	formatPtr := flag.String("f", "json", "Output format: json|yaml|toml|typescript|jsonschema|zod|valibot|go|python|rust")
 	mergeMode := flag.String("include-merge", "keep", "Include merge strategy: keep|overwrite|error")
 	// Streaming flags
 	streamingPtr := flag.Bool("stream", false, "Enable streaming mode for large datasets (reduces memory usage)")
 	streamThreshold := flag.Int64("stream-threshold", 10000, "Auto-enable streaming for ranges larger than N items")
+	// Sandbox flags for untrusted input
+	sandboxRoot := flag.String("sandbox-root", "", "Only allow includes and load() inside this directory (symlinks included)")
+	maxIncludeDepth := flag.Int("max-include-depth", 0, "Maximum include nesting depth (0 = unlimited)")
+	maxIncludeFiles := flag.Int("max-include-files", 0, "Maximum number of included or loaded files (0 = unlimited)")
+	maxElements := flag.Int64("max-elements", 0, "Maximum number of generated elements (0 = unlimited)")
+	maxOutputBytes := flag.Int64("max-output-bytes", 0, "Maximum output size in bytes (0 = unlimited)")
+	maxDepth := flag.Int("max-depth", 0, "Maximum expression nesting depth (0 = unlimited)")
+	timeout := flag.Duration("timeout", 0, "Abort evaluation after this long, e.g. 5s (0 = no timeout)")
+	parallelPtr := flag.Int("parallel", 0, "Evaluate large maps on N worker goroutines (0 = sequential)")
+	decimalPtr := flag.Bool("decimal", false, "Evaluate float literals as exact decimals (no binary float noise)")
+	tsModePtr := flag.String("ts-mode", "const", "TypeScript output: const|interfaces|dts (interfaces only, for a .d.ts)")
+	pyModePtr := flag.String("py-mode", "data", "Python output: data|pydantic")
+	goPackagePtr := flag.String("package", "config", "Package name of -f go output")
+	schemaPtr := flag.String("schema", "", "Validate the output against this JSON Schema file before writing it")
 	flag.Parse()
 
 	if *inputPtr == "" {
@@ -32,10 +61,12 @@ func main() {
 	validFormats := map[string]bool{
 		"json": true, "yaml": true, "toml": true,
 		"typescript": true, "ts": true,
+		"jsonschema": true, "zod": true, "valibot": true,
+		"go": true, "python": true, "py": true, "rust": true, "rs": true,
 	}
 
 	if !validFormats[format] {
-		fmt.Printf("Invalid format: %s. Must be json, yaml, toml or typescript\n", *formatPtr)
+		fmt.Printf("Invalid format: %s. Must be json, yaml, toml, typescript, jsonschema, zod, valibot, go, python or rust\n", *formatPtr)
 		os.Exit(1)
 	}
 
@@ -43,6 +74,31 @@ func main() {
 	if format == "ts" {
 		format = "typescript"
 	}
+	if format == "py" {
+		format = "python"
+	}
+	if format == "rs" {
+		format = "rust"
+	}
+
+	tsMode := strings.ToLower(*tsModePtr)
+	switch tsMode {
+	case transpiler.TypeScriptConst, transpiler.TypeScriptInterfaces, transpiler.TypeScriptDeclarations:
+	default:
+		fmt.Printf("Invalid TypeScript mode: %s. Must be const, interfaces or dts\n", *tsModePtr)
+		os.Exit(1)
+	}
+
+	pyMode := strings.ToLower(*pyModePtr)
+	if pyMode != transpiler.PythonData && pyMode != transpiler.PythonPydantic {
+		fmt.Printf("Invalid Python mode: %s. Must be data or pydantic\n", *pyModePtr)
+		os.Exit(1)
+	}
+
+	if !token.IsIdentifier(*goPackagePtr) {
+		fmt.Printf("Invalid package name: %s. Must be a Go identifier\n", *goPackagePtr)
+		os.Exit(1)
+	}
 
 	data, err := ioutil.ReadFile(*inputPtr)
 	if err != nil {
@@ -74,6 +130,59 @@ func main() {
 	t := transpiler.New(program, baseDir, *mergeMode, absInput)
 	// Configure streaming mode
 	t.SetStreamingMode(*streamingPtr, *streamThreshold)
+	t.SetDecimalMode(*decimalPtr)
+	t.SetParallelism(*parallelPtr)
+	t.SetTypeScriptMode(tsMode)
+	t.SetGoPackage(*goPackagePtr)
+	t.SetPythonMode(pyMode)
+	t.SetIncludeLimits(*maxIncludeDepth, *maxIncludeFiles)
+	t.SetLimits(transpiler.Limits{
+		MaxElements:    *maxElements,
+		MaxOutputBytes: *maxOutputBytes,
+		MaxDepth:       *maxDepth,
+		Timeout:        *timeout,
+	})
+
+	if *schemaPtr != "" {
+		schemaData, err := ioutil.ReadFile(*schemaPtr)
+		if err != nil {
+			fmt.Printf("Error reading schema: %v\n", err)
+			os.Exit(1)
+		}
+		schema, err := transpiler.ParseSchema(schemaData)
+		if err != nil {
+			fmt.Printf("Error in schema %s: %v\n", *schemaPtr, err)
+			os.Exit(1)
+		}
+		t.SetSchema(schema)
+	}
+
+	var resolver transpiler.IncludeResolver = transpiler.OSResolver{}
+	if *sandboxRoot != "" {
+		root, err := filepath.Abs(*sandboxRoot)
+		if err != nil {
+			fmt.Printf("Error resolving sandbox root: %v\n", err)
+			os.Exit(1)
+		}
+		resolver = transpiler.OSResolver{Root: root}
+	}
+	if len(includeDirs) > 0 {
+		searchPaths := make([]string, 0, len(includeDirs))
+		for _, dir := range includeDirs {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				fmt.Printf("Error resolving include path %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+			searchPaths = append(searchPaths, abs)
+		}
+		resolver = &transpiler.SearchPathResolver{Base: resolver, Paths: searchPaths}
+	}
+	t.SetResolver(resolver)
+
+	// Ctrl-C stops a long generation instead of leaving it running
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	// Start timing
 	startTime := time.Now()
@@ -81,14 +190,25 @@ func main() {
 	var output []byte
 	switch format {
 	case "json":
-		output, err = t.Transpile()
+		output, err = t.TranspileContext(ctx)
 	case "yaml":
-		output, err = t.TranspileToYAML()
+		output, err = t.TranspileToYAMLContext(ctx)
 	case "toml":
-		output, err = t.TranspileToTOML()
+		output, err = t.TranspileToTOMLContext(ctx)
 	case "typescript":
-		output, err = t.TranspileToTypeScript()
-
+		output, err = t.TranspileToTypeScriptContext(ctx)
+	case "jsonschema":
+		output, err = t.TranspileToJSONSchemaContext(ctx)
+	case "zod":
+		output, err = t.TranspileToZodContext(ctx)
+	case "valibot":
+		output, err = t.TranspileToValibotContext(ctx)
+	case "go":
+		output, err = t.TranspileToGoContext(ctx)
+	case "python":
+		output, err = t.TranspileToPythonContext(ctx)
+	case "rust":
+		output, err = t.TranspileToRustContext(ctx)
 	}
 
 	// Calculate elapsed time
@@ -9,6 +9,7 @@ import (
 	"jsson/internal/parser"
 	"jsson/internal/transpiler"
 	"syscall/js"
+	"time"
 )
 
 func transpile(this js.Value, args []js.Value) interface{} {
@@ -41,6 +42,24 @@ func transpile(this js.Value, args []js.Value) interface{} {
 	// Transpiler
 	t := transpiler.New(program, ".", "keep", "playground.jsson")
 
+	// Optional third argument: { "path.jsson": "source", ... } for includes and load()
+	files := map[string]string{}
+	if len(args) >= 3 && args[2].Type() == js.TypeObject {
+		keys := js.Global().Get("Object").Call("keys", args[2])
+		for i := 0; i < keys.Length(); i++ {
+			name := keys.Index(i).String()
+			files[name] = args[2].Get(name).String()
+		}
+	}
+	t.SetResolver(transpiler.NewMapResolver(files))
+	// The playground runs whatever is pasted into it
+	t.SetLimits(transpiler.Limits{
+		MaxElements:    1_000_000,
+		MaxOutputBytes: 16 << 20,
+		MaxDepth:       1000,
+		Timeout:        5 * time.Second,
+	})
+
 	var output []byte
 	var err error
 
@@ -51,6 +70,27 @@ func transpile(this js.Value, args []js.Value) interface{} {
 		output, err = t.TranspileToTOML()
 	case "typescript", "ts":
 		output, err = t.TranspileToTypeScript()
+	case "ts-interfaces":
+		t.SetTypeScriptMode(transpiler.TypeScriptInterfaces)
+		output, err = t.TranspileToTypeScript()
+	case "dts":
+		t.SetTypeScriptMode(transpiler.TypeScriptDeclarations)
+		output, err = t.TranspileToTypeScript()
+	case "jsonschema":
+		output, err = t.TranspileToJSONSchema()
+	case "zod":
+		output, err = t.TranspileToZod()
+	case "valibot":
+		output, err = t.TranspileToValibot()
+	case "go":
+		output, err = t.TranspileToGo()
+	case "python", "py":
+		output, err = t.TranspileToPython()
+	case "pydantic":
+		t.SetPythonMode(transpiler.PythonPydantic)
+		output, err = t.TranspileToPython()
+	case "rust", "rs":
+		output, err = t.TranspileToRust()
 	default:
 		output, err = t.Transpile()
 	}
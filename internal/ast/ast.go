@@ -3,6 +3,8 @@ package ast
 import (
 	"bytes"
 	"jsson/internal/token"
+	"strconv"
+	"strings"
 )
 
 type Node interface {
@@ -44,6 +46,8 @@ type AssignmentStatement struct {
 	Token token.Token // the token.IDENT
 	Name  *Identifier
 	Value Expression
+	Doc   string         // '///' doc comment written above the key, if any
+	Type  TypeExpression // type annotation (name: Type = value), if any
 }
 
 func (as *AssignmentStatement) statementNode()       {}
@@ -51,6 +55,10 @@ func (as *AssignmentStatement) TokenLiteral() string { return as.Token.Literal }
 func (as *AssignmentStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(as.Name.String())
+	if as.Type != nil {
+		out.WriteString(": ")
+		out.WriteString(as.Type.String())
+	}
 	out.WriteString(" = ")
 	if as.Value != nil {
 		out.WriteString(as.Value.String())
@@ -115,6 +123,14 @@ func (b *BooleanLiteral) expressionNode()      {}
 func (b *BooleanLiteral) TokenLiteral() string { return b.Token.Literal }
 func (b *BooleanLiteral) String() string       { return b.Token.Literal }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) String() string       { return nl.Token.Literal }
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -155,6 +171,10 @@ type ObjectLiteral struct {
 	Declarations []*VariableDeclaration // Local variables (key := value)
 	Properties   map[string]Expression  // Properties (key = value)
 	Keys         []string               // Para manter a ordem das chaves
+	Docs         map[string]string      // '///' doc comments by property key
+	KeyTokens    map[string]token.Token // key tokens by property key, for source positions
+	Includes     []*IncludeStatement    // includes merged into the object after its properties
+	Asserts      []*AssertStatement     // checked once the object's properties are known
 }
 
 func (o *ObjectLiteral) expressionNode()      {}
@@ -174,6 +194,29 @@ func (o *ObjectLiteral) String() string {
 	return out.String()
 }
 
+// Call: load("users.csv")
+type CallExpression struct {
+	Token     token.Token // '('
+	Function  *Identifier
+	Arguments []Expression
+}
+
+func (ce *CallExpression) expressionNode()      {}
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	for i, arg := range ce.Arguments {
+		out.WriteString(arg.String())
+		if i < len(ce.Arguments)-1 {
+			out.WriteString(", ")
+		}
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
 // Array: [ 1, 2, 3 ]
 type ArrayLiteral struct {
 	Token    token.Token // '['
@@ -222,16 +265,33 @@ func (be *BinaryExpression) String() string {
 	return "(" + be.Left.String() + " " + be.Operator + " " + be.Right.String() + ")"
 }
 
-// MemberExpression: item.path
+// PrefixExpression: -x, +x, !x
+type PrefixExpression struct {
+	Token    token.Token // The operator token
+	Operator string
+	Right    Expression
+}
+
+func (pe *PrefixExpression) expressionNode()      {}
+func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) String() string {
+	return "(" + pe.Operator + pe.Right.String() + ")"
+}
+
+// MemberExpression: item.path or item?.path
 type MemberExpression struct {
-	Token    token.Token // The '.' token
+	Token    token.Token // The '.' or '?.' token
 	Left     Expression
 	Property *Identifier
+	Optional bool // true for '?.', which yields null instead of failing
 }
 
 func (me *MemberExpression) expressionNode()      {}
 func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
 func (me *MemberExpression) String() string {
+	if me.Optional {
+		return me.Left.String() + "?." + me.Property.String()
+	}
 	return me.Left.String() + "." + me.Property.String()
 }
 
@@ -306,12 +366,38 @@ func (re *RangeExpression) String() string {
 type IncludeStatement struct {
 	Token token.Token // the 'include' token
 	Path  *StringLiteral
+	Keyed bool     // 'keyed': nest each file's output under its file name
+	Mount []string // 'as a.b': path the output is mounted at instead of the root
 }
 
 func (is *IncludeStatement) statementNode()       {}
 func (is *IncludeStatement) TokenLiteral() string { return is.Token.Literal }
 func (is *IncludeStatement) String() string {
-	return "include " + is.Path.String()
+	out := "include " + is.Path.String()
+	if is.Keyed {
+		out += " keyed"
+	}
+	if len(is.Mount) > 0 {
+		out += " as " + strings.Join(is.Mount, ".")
+	}
+	return out
+}
+
+// AssertStatement: assert condition, "message"
+type AssertStatement struct {
+	Token     token.Token // the 'assert' token
+	Condition Expression
+	Message   Expression // optional; the condition's source is reported without it
+}
+
+func (as *AssertStatement) statementNode()       {}
+func (as *AssertStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssertStatement) String() string {
+	out := "assert " + as.Condition.String()
+	if as.Message != nil {
+		out += ", " + as.Message.String()
+	}
+	return out
 }
 
 // ConditionalExpression: condition ? consequence : alternative
@@ -327,3 +413,127 @@ func (ce *ConditionalExpression) TokenLiteral() string { return ce.Token.Literal
 func (ce *ConditionalExpression) String() string {
 	return "(" + ce.Condition.String() + " ? " + ce.Consequence.String() + " : " + ce.Alternative.String() + ")"
 }
+
+// TypeDeclaration: type Deployment { name: string, replicas: int(1..50) }
+// or type Port = int(1..65535). 'schema' is accepted in place of 'type'.
+type TypeDeclaration struct {
+	Token token.Token // 'type' or 'schema'
+	Name  *Identifier
+	Type  TypeExpression
+	Doc   string
+}
+
+func (td *TypeDeclaration) statementNode()       {}
+func (td *TypeDeclaration) TokenLiteral() string { return td.Token.Literal }
+func (td *TypeDeclaration) String() string {
+	if _, ok := td.Type.(*ObjectType); ok {
+		return td.Token.Literal + " " + td.Name.String() + " " + td.Type.String()
+	}
+	return td.Token.Literal + " " + td.Name.String() + " = " + td.Type.String()
+}
+
+// TypeExpression is a type in a declaration or annotation
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
+// NamedType: a built-in type (string, int, float, number, bool, null, any) or
+// a declared one. Min and Max bound numbers, or the length of strings: int(1..50).
+type NamedType struct {
+	Token    token.Token // the name
+	Name     string
+	Min, Max Expression // nil when that side is open or there are no bounds
+	Bounded  bool
+}
+
+func (nt *NamedType) typeNode()            {}
+func (nt *NamedType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NamedType) String() string {
+	if !nt.Bounded {
+		return nt.Name
+	}
+	var out bytes.Buffer
+	out.WriteString(nt.Name + "(")
+	if nt.Min != nil {
+		out.WriteString(nt.Min.String())
+	}
+	out.WriteString("..")
+	if nt.Max != nil {
+		out.WriteString(nt.Max.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+// LiteralType: a single allowed value such as "prod", 3 or true
+type LiteralType struct {
+	Token token.Token
+	Value Expression
+}
+
+func (lt *LiteralType) typeNode()            {}
+func (lt *LiteralType) TokenLiteral() string { return lt.Token.Literal }
+func (lt *LiteralType) String() string {
+	if s, ok := lt.Value.(*StringLiteral); ok {
+		return strconv.Quote(s.Value)
+	}
+	return lt.Value.String()
+}
+
+// UnionType: "prod" | "staging" | "dev"
+type UnionType struct {
+	Token   token.Token // the first option's token
+	Options []TypeExpression
+}
+
+func (ut *UnionType) typeNode()            {}
+func (ut *UnionType) TokenLiteral() string { return ut.Token.Literal }
+func (ut *UnionType) String() string {
+	parts := make([]string, len(ut.Options))
+	for i, o := range ut.Options {
+		parts[i] = o.String()
+	}
+	return strings.Join(parts, " | ")
+}
+
+// ArrayType: [string]
+type ArrayType struct {
+	Token token.Token // '['
+	Elem  TypeExpression
+}
+
+func (at *ArrayType) typeNode()            {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) String() string       { return "[" + at.Elem.String() + "]" }
+
+// ObjectType: { name: string, labels?: { app: string } }
+type ObjectType struct {
+	Token  token.Token // '{'
+	Fields []*TypeField
+}
+
+func (ot *ObjectType) typeNode()            {}
+func (ot *ObjectType) TokenLiteral() string { return ot.Token.Literal }
+func (ot *ObjectType) String() string {
+	parts := make([]string, len(ot.Fields))
+	for i, f := range ot.Fields {
+		parts[i] = f.String()
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+// TypeField is one field of an ObjectType; optional fields may be left out
+type TypeField struct {
+	Token    token.Token // the field name
+	Name     string
+	Optional bool
+	Type     TypeExpression
+}
+
+func (tf *TypeField) String() string {
+	if tf.Optional {
+		return tf.Name + "?: " + tf.Type.String()
+	}
+	return tf.Name + ": " + tf.Type.String()
+}
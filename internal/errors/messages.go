@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // LexerError formats a lexer error with the "Lexer goblin" prefix and fun messaging
 func LexerError(sourceFile string, line, col int, format string, args ...interface{}) string {
@@ -42,11 +45,31 @@ func UnterminatedString() string {
 	return "found an endless string (missing closing quote)"
 }
 
+// UnterminatedBlockComment returns a fun message for a /* comment that never ends
+func UnterminatedBlockComment() string {
+	return "found a /* comment with no closing */ — the goblin read to the end of the file looking for it"
+}
+
+// InvalidEscape returns a fun message for an unknown escape sequence in a string
+func InvalidEscape(seq string) string {
+	return fmt.Sprintf("unknown escape %s in string — valid escapes are \\\" \\\\ \\/ \\b \\f \\n \\r \\t \\uXXXX and \\u{...}", seq)
+}
+
+// InvalidUnicodeEscape returns a fun message for a malformed \u escape
+func InvalidUnicodeEscape(seq, reason string) string {
+	return fmt.Sprintf("malformed unicode escape %s: %s — the goblin can't spell that character", seq, reason)
+}
+
 // IllegalCharacter returns a fun message for illegal characters
 func IllegalCharacter(ch rune) string {
 	return fmt.Sprintf("stumbled upon a strange character: %q", ch)
 }
 
+// MalformedNumber returns a fun message for numeric literals that don't parse
+func MalformedNumber(literal string) string {
+	return fmt.Sprintf("malformed number %q — goblin can't count like that", literal)
+}
+
 // ExpectedToken returns a fun message for expected tokens
 func ExpectedToken(expected, got string) string {
 	return fmt.Sprintf("expected %s but found %s instead", expected, got)
@@ -97,16 +120,37 @@ func UnsupportedBinaryOp(left interface{}, op string, right interface{}) string
 	return fmt.Sprintf("unsupported binary operation: %v %s %v — gremlin doesn't know how to do that math", left, op, right)
 }
 
+// UnsupportedPrefixOp returns a fun message for unary operators applied to the wrong type
+func UnsupportedPrefixOp(op, operandType, operand string) string {
+	return fmt.Sprintf("can't apply unary %s to %s %s — gremlin only does that to numbers", op, operandType, operand)
+}
+
+// MissingOperand returns a fun message for a unary operator with nothing after it
+func MissingOperand(op string) string {
+	return fmt.Sprintf("expected an expression after unary %s — wizard needs something to work on", op)
+}
+
 // IncludePathExpected returns a fun message when include needs a path
 func IncludePathExpected() string {
 	return "expected a path string after include — wizard needs directions"
 }
 
+// MountPathExpected returns a fun message for 'include ... as' without a key path
+func MountPathExpected(got string) string {
+	return fmt.Sprintf("expected a key path like 'database' or 'config.db' after 'as', found %q — wizard doesn't know where to put it", got)
+}
+
 // IntegerTooSpicy returns a fun message for unparseable integers
 func IntegerTooSpicy(literal string) string {
 	return fmt.Sprintf("could not parse %q as integer — maybe it's too spicy for me", literal)
 }
 
+// LeadingZeroInteger returns a fun message for integers like 0755, which used
+// to be octal
+func LeadingZeroInteger(literal, octal, decimal string) string {
+	return fmt.Sprintf("%q has a leading zero — wizard won't guess, write %s for octal or %s for decimal", literal, octal, decimal)
+}
+
 // ExpectedIdentifierAfterDot returns a fun message for member access errors
 func ExpectedIdentifierAfterDot() string {
 	return "expected identifier after '.' — maybe use letters, not emojis"
@@ -131,3 +175,213 @@ func MissingColonInTernary() string {
 func UnsupportedComparison(left, right interface{}) string {
 	return fmt.Sprintf("can't compare %v and %v — gremlin doesn't know how", left, right)
 }
+
+// NullInTOMLArray returns a fun message for null array elements in TOML output
+func NullInTOMLArray(path string) string {
+	return fmt.Sprintf("null at %s can't be written to TOML — gremlin found no null in the TOML spellbook", path)
+}
+
+// IntegerOverflow returns a fun message for integer arithmetic that leaves the int64 range
+func IntegerOverflow(expr string) string {
+	return fmt.Sprintf("integer overflow: %s doesn't fit in 64 bits — the gremlin's abacus ran out of beads!", expr)
+}
+
+// UnknownFunction returns a fun message for calls to functions that don't exist
+func UnknownFunction(name string) string {
+	return fmt.Sprintf("unknown function %s() — gremlin only knows load()", name)
+}
+
+// WrongArgumentCount returns a fun message for calls with the wrong number of arguments
+func WrongArgumentCount(name string, want, got int) string {
+	return fmt.Sprintf("%s() takes %d argument(s) but got %d — gremlin counted twice", name, want, got)
+}
+
+// LoadPathNotString returns a fun message when load() gets something other than a path
+func LoadPathNotString(arg interface{}) string {
+	return fmt.Sprintf("load() needs a file path string, got %v — gremlin can't open that", arg)
+}
+
+// UnsupportedLoadFormat returns a fun message for data files load() can't read
+func UnsupportedLoadFormat(path string) string {
+	return fmt.Sprintf("load() can't read %q — gremlin only reads .json, .yaml, .yml, .toml and .csv", path)
+}
+
+// LoadFailed returns a fun message when a data file can't be read or parsed
+func LoadFailed(path string, err error) string {
+	return fmt.Sprintf("could not load %q — gremlin choked on it: %v", path, err)
+}
+
+// NoIncludeMatches returns a fun message for a glob or directory include that finds nothing
+func NoIncludeMatches(pattern string) string {
+	return fmt.Sprintf("include %q matched no files — gremlin searched the whole directory", pattern)
+}
+
+// BadIncludePattern returns a fun message for a malformed glob pattern
+func BadIncludePattern(pattern string, err error) string {
+	return fmt.Sprintf("bad include pattern %q: %v — gremlin can't read that map", pattern, err)
+}
+
+// DuplicateIncludeKey returns a fun message for keyed includes whose file names collide
+func DuplicateIncludeKey(key, first, second string) string {
+	return fmt.Sprintf("keyed include: %s and %s both want the key %q — gremlin can't pick a favourite", first, second, key)
+}
+
+// MountNotObject returns a fun message when 'include ... as' runs into a non-object value
+func MountNotObject(path string, existing interface{}) string {
+	return fmt.Sprintf("can't mount include at %s: it already holds %v, not an object — gremlin won't stack files on that", path, existing)
+}
+
+// IncludeNotResolved returns a fun message when an include path can't be resolved at all
+func IncludeNotResolved(path string, err error) string {
+	return fmt.Sprintf("could not resolve include %q: %v — gremlin can't get there from here", path, err)
+}
+
+// IncludeTooDeep returns a fun message when includes nest past the configured depth
+func IncludeTooDeep(path string, max int) string {
+	return fmt.Sprintf("include of %s nests deeper than the limit of %d — gremlin won't dig that far", path, max)
+}
+
+// TooManyIncludeFiles returns a fun message when a run reads more files than allowed
+func TooManyIncludeFiles(max int) string {
+	return fmt.Sprintf("more than %d included or loaded files — gremlin's backpack is full", max)
+}
+
+// SandboxViolation returns a fun message for included or loaded files outside the sandbox root
+func SandboxViolation(path string, err error) string {
+	return fmt.Sprintf("%q blocked: %v — gremlin stays inside the sandbox", path, err)
+}
+
+// TooManyElements returns a fun message when ranges, maps or templates generate past the limit
+func TooManyElements(max int64) string {
+	return fmt.Sprintf("generated more than %d elements — gremlin stopped counting before the memory ran out", max)
+}
+
+// OutputTooLarge returns a fun message when the output or a string grows past the byte limit
+func OutputTooLarge(max int64) string {
+	return fmt.Sprintf("output would be larger than %d bytes — gremlin can't carry that much", max)
+}
+
+// NestingTooDeep returns a fun message when evaluation nests past the depth limit
+func NestingTooDeep(max int) string {
+	return fmt.Sprintf("expressions nest deeper than the limit of %d — gremlin got lost in there", max)
+}
+
+// EvaluationTimedOut returns a fun message when evaluation runs past its deadline
+func EvaluationTimedOut(timeout time.Duration) string {
+	return fmt.Sprintf("evaluation took longer than %s — gremlin clocked out", timeout)
+}
+
+// EvaluationCancelled returns a fun message when the caller cancels evaluation
+func EvaluationCancelled() string {
+	return "evaluation cancelled — gremlin put the pen down"
+}
+
+// RangeTooLarge returns a fun message for a range with more values than can be counted
+func RangeTooLarge(start, end, step int64) string {
+	return fmt.Sprintf("range %d..%d step %d has more values than gremlin can count", start, end, step)
+}
+
+// InvalidSchema returns a fun message for a JSON Schema that can't be used
+func InvalidSchema(detail string) string {
+	return fmt.Sprintf("the schema doesn't make sense: %s — gremlin can't check against that", detail)
+}
+
+// SchemaViolation returns a fun message for a value the schema rejects
+func SchemaViolation(path, problem string) string {
+	return fmt.Sprintf("%s %s — the schema bouncer won't let it through", path, problem)
+}
+
+// MoreSchemaViolations returns a fun message for violations left out of the report
+func MoreSchemaViolations(n int) string {
+	return fmt.Sprintf("...and %d more schema violations — gremlin stopped reading", n)
+}
+
+// TypeNameExpected returns a fun message when a type declaration has no name
+func TypeNameExpected(got string) string {
+	return fmt.Sprintf("expected a type name after type, found %q — wizard can't file it under nothing", got)
+}
+
+// TypeBodyExpected returns a fun message when a type declaration has no definition
+func TypeBodyExpected(name string) string {
+	return fmt.Sprintf("expected '{' or '=' after type %s — wizard needs to know what it looks like", name)
+}
+
+// TypeExpected returns a fun message for something that isn't a type
+func TypeExpected(got string) string {
+	return fmt.Sprintf("expected a type but found %q — wizard only knows string, int, float, bool, null, any and declared types", got)
+}
+
+// TypeBoundExpected returns a fun message for a type bound that isn't a number
+func TypeBoundExpected(got string) string {
+	return fmt.Sprintf("expected a number as a type bound, found %q — wizard measures with numbers", got)
+}
+
+// FieldTypeExpected returns a fun message for a type field without ': type'
+func FieldTypeExpected(field string) string {
+	return fmt.Sprintf("expected ':' and a type after field %s — wizard needs to know what goes there", field)
+}
+
+// AnnotationNotTopLevel returns a fun message for a type annotation inside an object
+func AnnotationNotTopLevel(name string) string {
+	return fmt.Sprintf("type annotations are only allowed at top level, not on %s — wizard suggests annotating the enclosing object instead", name)
+}
+
+// AnnotatedValueExpected returns a fun message for a type annotation with no value after it
+func AnnotatedValueExpected(name string) string {
+	return fmt.Sprintf("expected '=', '{' or '[' after the type of %s — wizard has a type but no value", name)
+}
+
+// UnknownType returns a fun message for a type name that was never declared
+func UnknownType(name string) string {
+	return fmt.Sprintf("unknown type %q — gremlin never saw it declared", name)
+}
+
+// DuplicateType returns a fun message for a type declared twice
+func DuplicateType(name string) string {
+	return fmt.Sprintf("type %s is declared more than once — gremlin doesn't know which one you mean", name)
+}
+
+// DuplicateTypeField returns a fun message for a field listed twice in one type
+func DuplicateTypeField(field string) string {
+	return fmt.Sprintf("field %s appears twice in the same type — gremlin sees double", field)
+}
+
+// BoundsNotSupported returns a fun message for bounds on a type that can't have them
+func BoundsNotSupported(name string) string {
+	return fmt.Sprintf("%s can't take bounds — gremlin only bounds int, float, number and string", name)
+}
+
+// BoundsReversed returns a fun message for bounds whose minimum is above the maximum
+func BoundsReversed(typ string) string {
+	return fmt.Sprintf("%s has its minimum above its maximum — no value can fit, gremlin checked", typ)
+}
+
+// TypeViolation returns a fun message for a value that doesn't fit its declared type
+func TypeViolation(path, problem, typ string) string {
+	return fmt.Sprintf("%s %s — it doesn't fit its type %s", path, problem, typ)
+}
+
+// CyclicType returns a fun message for a type defined only in terms of itself
+func CyclicType(name string) string {
+	return fmt.Sprintf("type %s is defined as itself — gremlin is chasing its own tail", name)
+}
+
+// AssertConditionExpected returns a fun message for an assert with nothing to check
+func AssertConditionExpected() string {
+	return "expected a condition after assert — gremlin has nothing to check"
+}
+
+// AssertionFailed returns a fun message for an assert whose condition is false
+func AssertionFailed(message string) string {
+	return fmt.Sprintf("assertion failed: %s — gremlin refuses to build this", message)
+}
+
+// GeneratedCodeInvalid returns a fun message for generated source that doesn't parse
+func GeneratedCodeInvalid(lang string, err error) string {
+	return fmt.Sprintf("generated %s doesn't parse (%v) — gremlin wrote nonsense", lang, err)
+}
+
+// GoEmptyKey returns a fun message for an empty key, which no Go json tag can name
+func GoEmptyKey(typeName string) string {
+	return fmt.Sprintf("%s has an empty key, which encoding/json can't map to a field — gremlin needs a name", typeName)
+}
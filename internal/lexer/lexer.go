@@ -4,7 +4,10 @@ import (
 	"fmt"
 	ie "jsson/internal/errors"
 	"jsson/internal/token"
+	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -17,6 +20,8 @@ type Lexer struct {
 	column       int
 	errors       []string
 	SourceFile   string
+	docLines     []string // pending '///' doc comment lines for the next token
+	commentErr   string   // error from an unterminated block comment
 }
 
 func New(input string) *Lexer {
@@ -28,6 +33,8 @@ func New(input string) *Lexer {
 func (l *Lexer) readChar() {
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
+		// Point past the last char so slices ending at position include it
+		l.position = l.readPosition
 	} else {
 		r, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
 		l.ch = r
@@ -37,11 +44,29 @@ func (l *Lexer) readChar() {
 	l.column++
 }
 
+// NextToken returns the next token, carrying any '///' doc comment lines that
+// directly precede it in Doc
 func (l *Lexer) NextToken() token.Token {
+	tok := l.nextToken()
+	if len(l.docLines) > 0 {
+		tok.Doc = strings.Join(l.docLines, "\n")
+		l.docLines = nil
+	}
+	return tok
+}
+
+func (l *Lexer) nextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
 
+	if l.commentErr != "" {
+		msg := l.commentErr
+		l.commentErr = ""
+		l.errors = append(l.errors, msg)
+		return l.newToken(token.ILLEGAL, msg)
+	}
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -57,9 +82,7 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			tok = token.Token{Type: token.NEQ, Literal: string(ch) + string(l.ch), Line: l.line, Column: l.column}
 		} else {
-			msg := l.lexErrMsg(ie.IllegalCharacter(l.ch))
-			l.errors = append(l.errors, msg)
-			tok = l.newToken(token.ILLEGAL, msg)
+			tok = l.newToken(token.BANG, string(l.ch))
 		}
 	case '<':
 		if l.peekChar() == '=' {
@@ -78,7 +101,18 @@ func (l *Lexer) NextToken() token.Token {
 			tok = l.newToken(token.GT, string(l.ch))
 		}
 	case '?':
-		tok = l.newToken(token.QUESTION, string(l.ch))
+		if l.peekChar() == '?' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: string(ch) + string(l.ch), Line: l.line, Column: l.column}
+		} else if l.peekChar() == '.' && !isDigit(l.peekSecondChar()) {
+			// "?." followed by a digit is a ternary with a fractional operand, not optional access
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.OPTCHAIN, Literal: string(ch) + string(l.ch), Line: l.line, Column: l.column}
+		} else {
+			tok = l.newToken(token.QUESTION, string(l.ch))
+		}
 	case ':':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -127,9 +161,7 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			tok = token.Token{Type: token.LOR, Literal: string(ch) + string(l.ch), Line: l.line, Column: l.column}
 		} else {
-			msg := l.lexErrMsg(ie.IllegalCharacter(l.ch))
-			l.errors = append(l.errors, msg)
-			tok = l.newToken(token.ILLEGAL, msg)
+			tok = l.newToken(token.PIPE, string(l.ch))
 		}
 	case '"':
 		// Check for triple-quoted raw string
@@ -162,13 +194,16 @@ func (l *Lexer) NextToken() token.Token {
 			return tok
 		}
 		// Regular string
-		lit, ok := l.readString()
+		lit, escErr, ok := l.readString()
 		tok.Line = l.line
 		tok.Column = l.column
 		if !ok {
 			msg := l.lexErrMsg(ie.UnterminatedString())
 			l.errors = append(l.errors, msg)
 			tok = l.newToken(token.ILLEGAL, msg)
+		} else if escErr != "" {
+			l.errors = append(l.errors, escErr)
+			tok = l.newToken(token.ILLEGAL, escErr)
 		} else {
 			tok.Type = token.STRING
 			tok.Literal = lit
@@ -220,6 +255,9 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.RANGE, Literal: literal, Line: l.line, Column: l.column}
+		} else if isDigit(l.peekChar()) {
+			// Leading-dot float such as .5
+			return l.readNumberToken()
 		} else {
 			tok = l.newToken(token.DOT, string(l.ch))
 		}
@@ -236,16 +274,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Column = l.column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			// Check if the number contains a decimal point
-			if containsDot(tok.Literal) {
-				tok.Type = token.FLOAT
-			} else {
-				tok.Type = token.INT
-			}
-			tok.Line = l.line
-			tok.Column = l.column
-			return tok
+			return l.readNumberToken()
 		} else {
 			msg := l.lexErrMsg(ie.IllegalCharacter(l.ch))
 			l.errors = append(l.errors, msg)
@@ -269,25 +298,128 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[start:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumberToken reads a numeric literal and classifies it as INT or FLOAT
+func (l *Lexer) readNumberToken() token.Token {
+	lit, isFloat, ok := l.readNumber()
+	if !ok {
+		msg := l.lexErrMsg(ie.MalformedNumber(lit))
+		l.errors = append(l.errors, msg)
+		return l.newToken(token.ILLEGAL, msg)
+	}
+	tok := l.newToken(token.INT, lit)
+	if isFloat {
+		tok.Type = token.FLOAT
+	}
+	return tok
+}
+
+// readNumber reads a numeric literal: decimal (42, 1_000), hexadecimal (0xFF),
+// binary (0b1010), octal (0o755), and floats with an optional fraction and
+// exponent (3.14, .5, 1e6, 2.5E-3). Underscores may separate digits.
+func (l *Lexer) readNumber() (string, bool, bool) {
 	// Build number literal from runes to avoid slicing/index off-by-one issues
 	var runes []rune
-	for isDigit(l.ch) {
-		runes = append(runes, l.ch)
-		l.readChar()
+	isFloat := false
+
+	readDigits := func(isValid func(rune) bool) {
+		for isValid(l.ch) || l.ch == '_' {
+			runes = append(runes, l.ch)
+			l.readChar()
+		}
 	}
-	if l.ch == '.' && isDigit(l.peekChar()) {
+
+	if radixDigit := radixDigitFunc(l.ch, l.peekChar()); radixDigit != nil {
+		runes = append(runes, l.ch)
+		l.readChar() // consume 0
 		runes = append(runes, l.ch)
-		l.readChar() // consume .
-		for isDigit(l.ch) {
+		l.readChar() // consume x, b or o
+		readDigits(radixDigit)
+		if len(runes) == 2 {
+			// prefix without digits, e.g. "0x"
+			return string(runes), false, false
+		}
+	} else {
+		readDigits(isDigit)
+		if l.ch == '.' && isDigit(l.peekChar()) {
+			isFloat = true
 			runes = append(runes, l.ch)
-			l.readChar()
+			l.readChar() // consume .
+			readDigits(isDigit)
+		}
+		if l.ch == 'e' || l.ch == 'E' {
+			next := l.peekChar()
+			if isDigit(next) || ((next == '+' || next == '-') && isDigit(l.peekSecondChar())) {
+				isFloat = true
+				runes = append(runes, l.ch)
+				l.readChar() // consume e
+				if l.ch == '+' || l.ch == '-' {
+					runes = append(runes, l.ch)
+					l.readChar()
+				}
+				readDigits(isDigit)
+			}
+		}
+	}
+
+	// A number running straight into letters or digits (0b102, 12px) is malformed
+	ok := true
+	for isLetter(l.ch) || isDigit(l.ch) {
+		ok = false
+		runes = append(runes, l.ch)
+		l.readChar()
+	}
+
+	lit := string(runes)
+	return lit, isFloat, ok && validUnderscores(lit)
+}
+
+// radixDigitFunc returns the digit predicate for a 0x, 0b or 0o prefix, or nil
+func radixDigitFunc(ch, next rune) func(rune) bool {
+	if ch != '0' {
+		return nil
+	}
+	switch next {
+	case 'x', 'X':
+		return func(r rune) bool {
+			return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
 		}
+	case 'b', 'B':
+		return func(r rune) bool { return r == '0' || r == '1' }
+	case 'o', 'O':
+		return func(r rune) bool { return r >= '0' && r <= '7' }
 	}
-	return string(runes)
+	return nil
 }
 
-func (l *Lexer) readString() (string, bool) {
+// validUnderscores checks that every '_' in a number sits between two digits
+func validUnderscores(lit string) bool {
+	runes := []rune(lit)
+	isSepDigit := isDigit
+	if len(runes) > 1 && (runes[1] == 'x' || runes[1] == 'X') {
+		isSepDigit = isHexDigit
+	}
+	for i, r := range runes {
+		if r != '_' {
+			continue
+		}
+		if i == 0 || i == len(runes)-1 {
+			return false
+		}
+		if !isSepDigit(runes[i-1]) || !isSepDigit(runes[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(ch rune) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// readString reads a double-quoted string, decoding JSON-compatible escapes
+// plus \u{...} code points. escErr holds the first malformed escape; the rest
+// of the string is still consumed so lexing resumes after the closing quote.
+func (l *Lexer) readString() (lit string, escErr string, ok bool) {
 	// consume opening quote
 	l.readChar()
 	var runes []rune
@@ -296,37 +428,127 @@ func (l *Lexer) readString() (string, bool) {
 		if l.ch == '"' {
 			// consume closing quote and return content
 			l.readChar()
-			return string(runes), true
+			return string(runes), escErr, true
 		}
 		if l.ch == '\\' {
-			// Handle escape sequences
-			l.readChar()
-			switch l.ch {
-			case 'n':
-				runes = append(runes, '\n')
-			case 't':
-				runes = append(runes, '\t')
-			case '"':
-				runes = append(runes, '"')
-			case '\\':
-				runes = append(runes, '\\')
-			default:
-				// Unknown escape, keep literal backslash and char
-				runes = append(runes, '\\')
-				runes = append(runes, l.ch)
+			r, msg := l.readEscape()
+			if msg != "" && escErr == "" {
+				escErr = msg
 			}
-			l.readChar()
+			runes = append(runes, r...)
 			continue
 		}
 		if l.ch == 0 {
 			// unterminated
-			return "", false
+			return "", escErr, false
 		}
 		runes = append(runes, l.ch)
 		l.readChar()
 	}
 }
 
+// readEscape decodes one escape sequence starting at the backslash and leaves
+// the lexer on the character after it. It returns an error message instead
+// of runes when the sequence is malformed.
+func (l *Lexer) readEscape() ([]rune, string) {
+	line, col := l.line, l.column
+	errAt := func(msg string) string {
+		return l.lexErrMsgAt(line, col, msg)
+	}
+
+	l.readChar() // consume backslash
+	esc := l.ch
+	switch esc {
+	case 'n':
+		l.readChar()
+		return []rune{'\n'}, ""
+	case 't':
+		l.readChar()
+		return []rune{'\t'}, ""
+	case 'r':
+		l.readChar()
+		return []rune{'\r'}, ""
+	case 'b':
+		l.readChar()
+		return []rune{'\b'}, ""
+	case 'f':
+		l.readChar()
+		return []rune{'\f'}, ""
+	case '"', '\\', '/':
+		l.readChar()
+		return []rune{esc}, ""
+	case 'u':
+		l.readChar()
+		return l.readUnicodeEscape(errAt)
+	case 0:
+		// Let readString report the unterminated string
+		return nil, ""
+	}
+	l.readChar()
+	return nil, errAt(ie.InvalidEscape("\\" + string(esc)))
+}
+
+// readUnicodeEscape decodes the part after \u: either four hex digits (with
+// surrogate pairs joined into one code point) or a braced code point \u{1F600}
+func (l *Lexer) readUnicodeEscape(errAt func(string) string) ([]rune, string) {
+	if l.ch == '{' {
+		l.readChar()
+		hex := ""
+		for isHexDigit(l.ch) && len(hex) <= 6 {
+			hex += string(l.ch)
+			l.readChar()
+		}
+		seq := "\\u{" + hex
+		if l.ch != '}' || hex == "" || len(hex) > 6 {
+			return nil, errAt(ie.InvalidUnicodeEscape(seq, "expected 1 to 6 hex digits followed by '}'"))
+		}
+		l.readChar()
+		seq += "}"
+		cp, _ := strconv.ParseUint(hex, 16, 32)
+		if cp > unicode.MaxRune || utf16.IsSurrogate(rune(cp)) {
+			return nil, errAt(ie.InvalidUnicodeEscape(seq, "not a valid Unicode code point"))
+		}
+		return []rune{rune(cp)}, ""
+	}
+
+	r, seq, ok := l.readHex4()
+	if !ok {
+		return nil, errAt(ie.InvalidUnicodeEscape(seq, "expected exactly 4 hex digits"))
+	}
+	if !utf16.IsSurrogate(r) {
+		return []rune{r}, ""
+	}
+	// A high surrogate must be followed by an escaped low surrogate
+	if r < 0xDC00 && l.ch == '\\' && l.peekChar() == 'u' {
+		l.readChar()
+		l.readChar()
+		low, lowSeq, ok := l.readHex4()
+		if !ok {
+			return nil, errAt(ie.InvalidUnicodeEscape(lowSeq, "expected exactly 4 hex digits"))
+		}
+		if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+			return []rune{pair}, ""
+		}
+		seq += lowSeq
+	}
+	return nil, errAt(ie.InvalidUnicodeEscape(seq, "unpaired UTF-16 surrogate"))
+}
+
+// readHex4 reads the four hex digits of a \uXXXX escape
+func (l *Lexer) readHex4() (rune, string, bool) {
+	hex := ""
+	for i := 0; i < 4 && isHexDigit(l.ch); i++ {
+		hex += string(l.ch)
+		l.readChar()
+	}
+	seq := "\\u" + hex
+	if len(hex) != 4 {
+		return 0, seq, false
+	}
+	v, _ := strconv.ParseUint(hex, 16, 32)
+	return rune(v), seq, true
+}
+
 // readRawString reads a triple-quoted raw string ("""...""")
 // It preserves ALL content literally - no escape processing
 func (l *Lexer) readRawString() (string, bool) {
@@ -438,11 +660,16 @@ func (l *Lexer) lexErrf(format string, args ...interface{}) string {
 
 // lexErrMsg formats an already-formatted error message with context
 func (l *Lexer) lexErrMsg(msg string) string {
+	return l.lexErrMsgAt(l.line, l.column, msg)
+}
+
+// lexErrMsgAt is lexErrMsg for a position other than the current one
+func (l *Lexer) lexErrMsgAt(line, col int, msg string) string {
 	if l.SourceFile != "" {
-		ctx := ie.FormatContext(l.SourceFile, l.line, l.column)
+		ctx := ie.FormatContext(l.SourceFile, line, col)
 		return fmt.Sprintf("Lex goblin: %s — %s", ctx, msg)
 	}
-	ctx := fmt.Sprintf("%d:%d", l.line, l.column)
+	ctx := fmt.Sprintf("%d:%d", line, col)
 	return fmt.Sprintf("Lex goblin: %s — %s", ctx, msg)
 }
 
@@ -455,9 +682,17 @@ func (l *Lexer) SetSourceFile(path string) {
 }
 
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' || (l.ch == '/' && l.peekChar() == '/') {
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' || (l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*')) {
 		if l.ch == '/' && l.peekChar() == '/' {
-			l.skipComment()
+			if l.peekSecondChar() == '/' {
+				l.readDocComment()
+			} else {
+				l.skipComment()
+			}
+			continue
+		}
+		if l.ch == '/' && l.peekChar() == '*' {
+			l.skipBlockComment()
 			continue
 		}
 		if l.ch == '\n' {
@@ -479,6 +714,52 @@ func (l *Lexer) skipComment() {
 	}
 }
 
+// skipBlockComment skips a /* ... */ comment, which may span lines
+func (l *Lexer) skipBlockComment() {
+	line, col := l.line, l.column
+	l.readChar() // consume '/'
+	l.readChar() // consume '*'
+	for {
+		if l.ch == 0 {
+			l.commentErr = l.lexErrMsgAt(line, col, ie.UnterminatedBlockComment())
+			return
+		}
+		if l.ch == '*' && l.peekChar() == '/' {
+			l.readChar()
+			l.readChar()
+			return
+		}
+		if l.ch == '\n' {
+			l.line++
+			l.column = 0
+		}
+		l.readChar()
+	}
+}
+
+// readDocComment consumes a '///' line and queues its text for the next token.
+// Four or more slashes are an ordinary comment, so '////' banners stay private.
+func (l *Lexer) readDocComment() {
+	l.readChar()
+	l.readChar()
+	l.readChar()
+	if l.ch == '/' {
+		l.skipComment()
+		return
+	}
+	start := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	text := strings.TrimRight(l.input[start:l.position], " \t\r")
+	l.docLines = append(l.docLines, strings.TrimPrefix(text, " "))
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+		l.readChar()
+	}
+}
+
 func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
@@ -487,6 +768,19 @@ func (l *Lexer) peekChar() rune {
 	return r
 }
 
+// peekSecondChar returns the character two positions ahead without consuming anything
+func (l *Lexer) peekSecondChar() rune {
+	if l.readPosition >= len(l.input) {
+		return 0
+	}
+	_, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	if l.readPosition+width >= len(l.input) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.readPosition+width:])
+	return r
+}
+
 func isLetter(ch rune) bool {
 	return unicode.IsLetter(ch) || ch == '_'
 }
@@ -494,12 +788,3 @@ func isLetter(ch rune) bool {
 func isDigit(ch rune) bool {
 	return unicode.IsDigit(ch)
 }
-
-func containsDot(s string) bool {
-	for _, ch := range s {
-		if ch == '.' {
-			return true
-		}
-	}
-	return false
-}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"jsson/internal/ast"
 	ie "jsson/internal/errors"
@@ -14,6 +15,7 @@ const (
 	_ int = iota
 	LOWEST
 	TERNARY     // ? :
+	COALESCE    // ??
 	LOGICAL     // && ||
 	EQUALS      // == !=
 	LESSGREATER // > < >= <=
@@ -42,7 +44,9 @@ var precedences = map[token.TokenType]int{
 	token.ASTERISK: PRODUCT,
 	token.MODULO:   PRODUCT,
 	token.QUESTION: TERNARY,
+	token.NULLISH:  COALESCE,
 	token.DOT:      INDEX,
+	token.OPTCHAIN: INDEX,
 	token.RANGE:    RANGE,
 	token.MAP:      MAP,
 }
@@ -55,11 +59,16 @@ type Parser struct {
 }
 
 func (p *Parser) addError(msg string) {
+	p.addErrorAt(p.curToken, msg)
+}
+
+// addErrorAt reports msg at tok instead of the current token
+func (p *Parser) addErrorAt(tok token.Token, msg string) {
 	var loc string
 	if p.l != nil && p.l.SourceFile != "" {
-		loc = ie.FormatContext(p.l.SourceFile, p.curToken.Line, p.curToken.Column)
+		loc = ie.FormatContext(p.l.SourceFile, tok.Line, tok.Column)
 	} else {
-		loc = fmt.Sprintf("%d:%d", p.curToken.Line, p.curToken.Column)
+		loc = fmt.Sprintf("%d:%d", tok.Line, tok.Column)
 	}
 	fun := "Syntax wizard:"
 	p.errors = append(p.errors, fmt.Sprintf("%s %s — %s", fun, loc, msg))
@@ -109,7 +118,14 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.IDENT:
 		// Could be Assignment (key = val), VariableDeclaration (key := val), Object (key { ... }) or ArrayTemplate (key [ ... ])
-		if p.peekToken.Type == token.DECLARE {
+		// 'type' and 'schema' only start a declaration when a name follows, so they still work as keys
+		if (p.curToken.Literal == "type" || p.curToken.Literal == "schema") && p.peekToken.Type == token.IDENT {
+			return p.parseTypeDeclaration()
+		} else if p.isAssert() {
+			return p.parseAssertStatement()
+		} else if p.peekToken.Type == token.COLON {
+			return p.parseAnnotatedStatement()
+		} else if p.peekToken.Type == token.DECLARE {
 			return p.parseVariableDeclaration()
 		} else if p.peekToken.Type == token.ASSIGN {
 			return p.parseAssignment()
@@ -120,6 +136,10 @@ func (p *Parser) parseStatement() ast.Statement {
 		} else {
 			return nil
 		}
+	case token.ILLEGAL:
+		// The lexer already formatted the message with its position
+		p.errors = append(p.errors, p.curToken.Literal)
+		return nil
 	default:
 		if p.curToken.Type == token.INCLUDE {
 			return p.parseIncludeStatement()
@@ -128,6 +148,43 @@ func (p *Parser) parseStatement() ast.Statement {
 	}
 }
 
+// isAssert reports whether the current 'assert' starts an assertion rather
+// than naming a key: the condition has to follow on the same line
+func (p *Parser) isAssert() bool {
+	if p.curToken.Literal != "assert" || p.peekToken.Line != p.curToken.Line {
+		return false
+	}
+	switch p.peekToken.Type {
+	case token.ASSIGN, token.DECLARE, token.COLON, token.LBRACE, token.LBRACKET,
+		token.COMMA, token.RBRACE, token.EOF:
+		return false
+	}
+	return true
+}
+
+func (p *Parser) parseAssertStatement() *ast.AssertStatement {
+	stmt := &ast.AssertStatement{Token: p.curToken}
+
+	p.nextToken() // consume assert
+	stmt.Condition = p.parseExpression(LOWEST)
+	if stmt.Condition == nil {
+		p.addError(ie.AssertConditionExpected())
+		return nil
+	}
+
+	// The message is a string after a comma; anything else after the comma is
+	// the next property of the enclosing object
+	if p.peekToken.Type == token.COMMA {
+		p.nextToken()
+		switch p.peekToken.Type {
+		case token.STRING, token.RAWSTRING, token.TEMPLATESTR:
+			p.nextToken()
+			stmt.Message = p.parseExpression(LOWEST)
+		}
+	}
+	return stmt
+}
+
 func (p *Parser) parseIncludeStatement() *ast.IncludeStatement {
 	stmt := &ast.IncludeStatement{Token: p.curToken}
 
@@ -139,11 +196,36 @@ func (p *Parser) parseIncludeStatement() *ast.IncludeStatement {
 	}
 
 	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	// 'keyed' and 'as' are contextual: only modifiers when they follow the path on the same line
+	for p.peekToken.Type == token.IDENT && p.peekToken.Line == stmt.Token.Line {
+		switch p.peekToken.Literal {
+		case "keyed":
+			p.nextToken()
+			stmt.Keyed = true
+		case "as":
+			p.nextToken() // move to 'as'
+			for {
+				if p.peekToken.Type != token.IDENT {
+					p.addError(ie.MountPathExpected(p.peekToken.Literal))
+					return nil
+				}
+				p.nextToken()
+				stmt.Mount = append(stmt.Mount, p.curToken.Literal)
+				if p.peekToken.Type != token.DOT {
+					break
+				}
+				p.nextToken() // move to '.'
+			}
+		default:
+			return stmt
+		}
+	}
 	return stmt
 }
 
 func (p *Parser) parseAssignment() *ast.AssignmentStatement {
-	stmt := &ast.AssignmentStatement{Token: p.curToken}
+	stmt := &ast.AssignmentStatement{Token: p.curToken, Doc: p.curToken.Doc}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	p.nextToken() // consume IDENT
@@ -167,7 +249,7 @@ func (p *Parser) parseVariableDeclaration() *ast.VariableDeclaration {
 }
 
 func (p *Parser) parseObjectStatement() *ast.AssignmentStatement {
-	stmt := &ast.AssignmentStatement{Token: p.curToken}
+	stmt := &ast.AssignmentStatement{Token: p.curToken, Doc: p.curToken.Doc}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	p.nextToken() // consume IDENT
@@ -176,7 +258,7 @@ func (p *Parser) parseObjectStatement() *ast.AssignmentStatement {
 }
 
 func (p *Parser) parseArrayTemplateStatement() *ast.AssignmentStatement {
-	stmt := &ast.AssignmentStatement{Token: p.curToken}
+	stmt := &ast.AssignmentStatement{Token: p.curToken, Doc: p.curToken.Doc}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	p.nextToken() // consume IDENT
@@ -204,7 +286,13 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 func (p *Parser) parsePrefix() ast.Expression {
 	switch p.curToken.Type {
 	case token.IDENT:
-		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		// A call needs '(' right after the name, so "name (expr)" in a row stays two values
+		if p.peekToken.Type == token.LPAREN && p.peekToken.Line == p.curToken.Line && p.peekToken.Column == p.curToken.Column {
+			p.nextToken()
+			return p.parseCallExpression(ident)
+		}
+		return ident
 	case token.INT:
 		return p.parseIntegerLiteral()
 	case token.FLOAT:
@@ -213,15 +301,20 @@ func (p *Parser) parsePrefix() ast.Expression {
 		return p.parseStringLiteral()
 	case token.TRUE, token.FALSE:
 		return p.parseBooleanLiteral()
+	case token.NULL:
+		return &ast.NullLiteral{Token: p.curToken}
 	case token.LPAREN:
 		return p.parseGroupedExpression()
 	case token.LBRACKET:
 		return p.parseArrayLiteral()
 	case token.LBRACE:
 		return p.parseObjectLiteral()
-	case token.MINUS:
-		// Unary minus for negative numbers
+	case token.MINUS, token.PLUS, token.BANG:
 		return p.parsePrefixExpression()
+	case token.ILLEGAL:
+		// The lexer already formatted the message with its position
+		p.errors = append(p.errors, p.curToken.Literal)
+		return nil
 	default:
 		return nil
 	}
@@ -231,13 +324,13 @@ func (p *Parser) parseInfix(left ast.Expression) ast.Expression {
 	switch p.peekToken.Type {
 	case token.PLUS, token.MINUS, token.SLASH, token.ASTERISK, token.MODULO,
 		token.EQ, token.NEQ, token.LT, token.GT, token.LTE, token.GTE,
-		token.LAND, token.LOR:
+		token.LAND, token.LOR, token.NULLISH:
 		p.nextToken()
 		return p.parseBinaryExpression(left)
 	case token.QUESTION:
 		p.nextToken()
 		return p.parseConditionalExpression(left)
-	case token.DOT:
+	case token.DOT, token.OPTCHAIN:
 		p.nextToken()
 		return p.parseMemberExpression(left)
 	case token.RANGE:
@@ -295,8 +388,8 @@ func (p *Parser) parseBinaryExpression(left ast.Expression) ast.Expression {
 }
 
 func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
-	expr := &ast.MemberExpression{Token: p.curToken, Left: left}
-	p.nextToken() // consume .
+	expr := &ast.MemberExpression{Token: p.curToken, Left: left, Optional: p.curToken.Type == token.OPTCHAIN}
+	p.nextToken() // consume . or ?.
 
 	if p.curToken.Type != token.IDENT {
 		p.addError(ie.ExpectedIdentifierAfterDot())
@@ -330,6 +423,37 @@ func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expres
 	return expr
 }
 
+// parseCallExpression parses the argument list of name(arg, ...); curToken is '('
+func (p *Parser) parseCallExpression(fn *ast.Identifier) ast.Expression {
+	call := &ast.CallExpression{Token: p.curToken, Function: fn}
+
+	if p.peekToken.Type == token.RPAREN {
+		p.nextToken()
+		return call
+	}
+
+	p.nextToken() // consume (
+	for {
+		arg := p.parseExpression(LOWEST)
+		if arg == nil {
+			return nil
+		}
+		call.Arguments = append(call.Arguments, arg)
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken() // move to ,
+		p.nextToken() // move to next argument
+	}
+
+	if p.peekToken.Type != token.RPAREN {
+		p.addError(ie.MissingClosingParen())
+		return nil
+	}
+	p.nextToken()
+	return call
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 	exp := p.parseExpression(LOWEST)
@@ -342,36 +466,41 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
-	// Handle unary minus for negative numbers
-	p.nextToken() // consume MINUS
-
-	// Check if next token is a number
-	if p.curToken.Type == token.INT {
-		lit := &ast.IntegerLiteral{Token: p.curToken}
-		value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
-		if err != nil {
-			p.addError(ie.IntegerTooSpicy(p.curToken.Literal))
-			return nil
-		}
-		lit.Value = -value // Negate the value
-		return lit
-	} else if p.curToken.Type == token.FLOAT {
-		lit := &ast.FloatLiteral{Token: p.curToken}
-		value, err := strconv.ParseFloat(p.curToken.Literal, 64)
-		if err != nil {
-			p.addError(fmt.Sprintf("could not parse %q as float", p.curToken.Literal))
-			return nil
+	opToken := p.curToken
+	p.nextToken() // consume operator
+
+	// Fold a minus applied directly to a number into a negative literal
+	if opToken.Type == token.MINUS {
+		numToken := p.curToken
+		numToken.Literal = "-" + numToken.Literal
+		numToken.Line, numToken.Column = opToken.Line, opToken.Column
+
+		if p.curToken.Type == token.INT {
+			lit := &ast.IntegerLiteral{Token: numToken}
+			value, err := parseIntLiteral(numToken.Literal)
+			if err != nil {
+				p.addError(intLiteralError(numToken.Literal, err))
+				return nil
+			}
+			lit.Value = value
+			return lit
+		} else if p.curToken.Type == token.FLOAT {
+			lit := &ast.FloatLiteral{Token: numToken}
+			value, err := parseFloatLiteral(numToken.Literal)
+			if err != nil {
+				p.addError(fmt.Sprintf("could not parse %q as float", numToken.Literal))
+				return nil
+			}
+			lit.Value = value
+			return lit
 		}
-		lit.Value = -value // Negate the value
-		return lit
 	}
 
-	// For other expressions, use the old binary expression approach
-	expr := &ast.BinaryExpression{
-		Token:    p.curToken,
-		Operator: "-",
-		Left:     &ast.IntegerLiteral{Token: p.curToken, Value: 0}, // 0 - value
-		Right:    p.parseExpression(PREFIX),
+	expr := &ast.PrefixExpression{Token: opToken, Operator: opToken.Literal}
+	expr.Right = p.parseExpression(PREFIX)
+	if expr.Right == nil {
+		p.addError(ie.MissingOperand(opToken.Literal))
+		return nil
 	}
 	return expr
 }
@@ -460,9 +589,9 @@ func (p *Parser) parseMapClause() *ast.MapClause {
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	value, err := parseIntLiteral(p.curToken.Literal)
 	if err != nil {
-		p.addError(ie.IntegerTooSpicy(p.curToken.Literal))
+		p.addError(intLiteralError(p.curToken.Literal, err))
 		return nil
 	}
 	lit.Value = value
@@ -471,7 +600,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 func (p *Parser) parseFloatLiteral() ast.Expression {
 	lit := &ast.FloatLiteral{Token: p.curToken}
-	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	value, err := parseFloatLiteral(p.curToken.Literal)
 	if err != nil {
 		p.addError(fmt.Sprintf("could not parse %q as float", p.curToken.Literal))
 		return nil
@@ -480,6 +609,44 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	return lit
 }
 
+// errLeadingZero rejects integers like 0755: older versions read them as
+// octal, so neither meaning can be assumed silently
+var errLeadingZero = errors.New("leading zero")
+
+// parseIntLiteral parses an integer literal with optional sign, 0x/0b/0o prefix
+// and '_' separators. Without a prefix the literal is decimal; a leading zero
+// followed by more digits is an error.
+func parseIntLiteral(literal string) (int64, error) {
+	clean := strings.ReplaceAll(literal, "_", "")
+	digits := strings.TrimLeft(clean, "+-")
+	if len(digits) > 1 && digits[0] == '0' {
+		if strings.ContainsAny(digits[1:2], "xXbBoO") {
+			return strconv.ParseInt(clean, 0, 64)
+		}
+		return 0, errLeadingZero
+	}
+	return strconv.ParseInt(clean, 10, 64)
+}
+
+// intLiteralError words the parse error of an integer literal
+func intLiteralError(literal string, err error) string {
+	if !errors.Is(err, errLeadingZero) {
+		return ie.IntegerTooSpicy(literal)
+	}
+	clean := strings.ReplaceAll(literal, "_", "")
+	sign := clean[:len(clean)-len(strings.TrimLeft(clean, "+-"))]
+	digits := strings.TrimLeft(clean[len(sign):], "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return ie.LeadingZeroInteger(literal, sign+"0o"+digits, sign+digits)
+}
+
+// parseFloatLiteral parses a float literal, ignoring '_' separators
+func parseFloatLiteral(literal string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(literal, "_", ""), 64)
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	isRaw := p.curToken.Type == token.RAWSTRING
 	isTemplate := p.curToken.Type == token.TEMPLATESTR
@@ -635,25 +802,62 @@ func (p *Parser) parseBooleanLiteral() ast.Expression {
 	return &ast.BooleanLiteral{Token: p.curToken, Value: p.curToken.Type == token.TRUE}
 }
 
+// peekContinuesAnnotation reports whether the value after "key:" in an object
+// is followed on its line by '=', '|', '{' or '[', which no value can be: the
+// property was written as a type annotation (key: Type = value)
+func (p *Parser) peekContinuesAnnotation() bool {
+	if p.peekToken.Line != p.curToken.Line {
+		return false
+	}
+	switch p.peekToken.Type {
+	case token.ASSIGN, token.PIPE, token.LBRACE, token.LBRACKET:
+		return true
+	}
+	return false
+}
+
 func (p *Parser) parseObjectLiteral() ast.Expression {
 	obj := &ast.ObjectLiteral{Token: p.curToken}
 	obj.Properties = make(map[string]ast.Expression)
 	obj.Keys = []string{}
+	obj.KeyTokens = make(map[string]token.Token)
 	obj.Declarations = []*ast.VariableDeclaration{} // Initialize declarations
 
 	p.nextToken() // consume {
 
 	for p.curToken.Type != token.RBRACE && p.curToken.Type != token.EOF {
+		if p.curToken.Type == token.INCLUDE {
+			if inc := p.parseIncludeStatement(); inc != nil {
+				obj.Includes = append(obj.Includes, inc)
+			}
+			p.nextToken()
+			continue
+		}
+		if p.curToken.Type == token.IDENT && p.isAssert() {
+			if as := p.parseAssertStatement(); as != nil {
+				obj.Asserts = append(obj.Asserts, as)
+			}
+			p.nextToken()
+			continue
+		}
 		if p.curToken.Type != token.IDENT {
 			p.nextToken()
 			continue
 		}
 
 		key := p.curToken.Literal
+		keyToken := p.curToken
+		if doc := p.curToken.Doc; doc != "" {
+			if obj.Docs == nil {
+				obj.Docs = make(map[string]string)
+			}
+			obj.Docs[key] = doc
+		}
 		p.nextToken() // consume key
 
 		// Check if it's a variable declaration (:=) or property assignment (=)
-		if p.curToken.Type == token.DECLARE {
+		isDeclaration := p.curToken.Type == token.DECLARE
+		if isDeclaration {
 			// Variable declaration: key := value
 			p.nextToken() // consume :=
 			val := p.parseExpression(LOWEST)
@@ -666,10 +870,19 @@ func (p *Parser) parseObjectLiteral() ast.Expression {
 			p.nextToken() // consume value
 		} else if p.curToken.Type == token.ASSIGN || p.curToken.Type == token.COLON {
 			// Property assignment: key = value
+			colon := p.curToken.Type == token.COLON
 			obj.Keys = append(obj.Keys, key)
 			p.nextToken() // consume = or :
 			val := p.parseExpression(LOWEST)
 			obj.Properties[key] = val
+			if colon && p.peekContinuesAnnotation() {
+				p.addErrorAt(keyToken, ie.AnnotationNotTopLevel(key))
+				if p.peekToken.Type == token.ASSIGN {
+					p.nextToken()
+					p.nextToken()
+					p.parseExpression(LOWEST)
+				}
+			}
 			p.nextToken() // consume value
 		} else if p.curToken.Type == token.LBRACE {
 			obj.Keys = append(obj.Keys, key)
@@ -687,6 +900,10 @@ func (p *Parser) parseObjectLiteral() ast.Expression {
 			obj.Properties[key] = nil
 		}
 
+		if !isDeclaration {
+			obj.KeyTokens[key] = keyToken
+		}
+
 		if p.curToken.Type == token.COMMA {
 			p.nextToken()
 		}
@@ -4,6 +4,7 @@ import (
 	"jsson/internal/ast"
 	"jsson/internal/lexer"
 	"jsson/internal/token"
+	"strings"
 	"testing"
 )
 
@@ -104,3 +105,469 @@ func TestParseIncludeStatement(t *testing.T) {
 		t.Fatalf("stmt not *ast.IncludeStatement. got=%T", program.Statements[0])
 	}
 }
+
+func TestParseNullCoalescingAndOptionalChain(t *testing.T) {
+	input := "x = a?.b ?? null"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	stmt, ok := program.Statements[0].(*ast.AssignmentStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.AssignmentStatement. got=%T", program.Statements[0])
+	}
+
+	be, ok := stmt.Value.(*ast.BinaryExpression)
+	if !ok || be.Operator != "??" {
+		t.Fatalf("value not ?? expression. got=%T (%v)", stmt.Value, stmt.Value)
+	}
+	me, ok := be.Left.(*ast.MemberExpression)
+	if !ok || !me.Optional {
+		t.Fatalf("left not optional member expression. got=%v", be.Left)
+	}
+	if _, ok := be.Right.(*ast.NullLiteral); !ok {
+		t.Fatalf("right not *ast.NullLiteral. got=%T", be.Right)
+	}
+}
+
+func TestParsePrefixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = !a", "(!a)"},
+		{"x = -a * 2", "((-a) * 2)"},
+		{"x = +a", "(+a)"},
+		{"x = !a == b", "((!a) == b)"},
+		{"x = -5", "-5"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+
+		stmt := program.Statements[0].(*ast.AssignmentStatement)
+		if got := stmt.Value.String(); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+
+	// -5 folds into a literal
+	l := lexer.New("x = -5")
+	p := New(l)
+	program := p.ParseProgram()
+	lit, ok := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.IntegerLiteral)
+	if !ok || lit.Value != -5 {
+		t.Fatalf("expected folded integer literal -5, got %v", program.Statements[0])
+	}
+}
+
+func TestParseNumericLiterals(t *testing.T) {
+	intTests := []struct {
+		input    string
+		expected int64
+	}{
+		{"x = 0xFF", 255},
+		{"x = 0b1010", 10},
+		{"x = 0o755", 493},
+		{"x = 1_000_000", 1000000},
+		{"x = 0x_ff_ff", -1}, // invalid: '_' right after the prefix
+		{"x = 007", -1},      // invalid: leading zero, octal or decimal is ambiguous
+		{"x = -0x10", -16},
+	}
+
+	for _, tt := range intTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if tt.expected == -1 {
+			if len(p.Errors()) == 0 {
+				t.Errorf("%q: expected a parse error", tt.input)
+			}
+			continue
+		}
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+		lit, ok := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.IntegerLiteral)
+		if !ok || lit.Value != tt.expected {
+			t.Errorf("%q: expected %d, got %v", tt.input, tt.expected, program.Statements[0])
+		}
+	}
+
+	floatTests := []struct {
+		input    string
+		expected float64
+	}{
+		{"x = 1e6", 1e6},
+		{"x = 2.5E-3", 0.0025},
+		{"x = .5", 0.5},
+		{"x = 1_000.5", 1000.5},
+		{"x = -1e3", -1000},
+	}
+
+	for _, tt := range floatTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+		lit, ok := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.FloatLiteral)
+		if !ok || lit.Value != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.input, tt.expected, program.Statements[0])
+		}
+	}
+}
+
+func TestParseLeadingZeroIntegers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"mode = 0755", `1:12 — "0755" has a leading zero — wizard won't guess, write 0o755 for octal or 755 for decimal`},
+		{"mode = -0_644", `1:14 — "-0_644" has a leading zero — wizard won't guess, write -0o644 for octal or -644 for decimal`},
+		{"mode = 00", `write 0o0 for octal or 0 for decimal`},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if errs := p.Errors(); len(errs) == 0 || !strings.Contains(errs[0], tt.want) {
+			t.Errorf("%q: expected %q in errors, got %v", tt.input, tt.want, errs)
+		}
+	}
+
+	// 0 alone and prefixed literals are fine
+	for _, input := range []string{"mode = 0", "mode = 0o755", "mode = -0"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Errorf("%q: unexpected errors %v", input, p.Errors())
+		}
+	}
+}
+
+func TestParseMalformedNumbers(t *testing.T) {
+	for _, input := range []string{"x = 0b102", "x = 0x", "x = 1__0", "x = 10_", "x = 12px"} {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected a malformed number error", input)
+			continue
+		}
+		if !strings.Contains(p.Errors()[0], "malformed number") {
+			t.Errorf("%q: unexpected error: %v", input, p.Errors())
+		}
+	}
+}
+
+func TestRangeWithNumericLiterals(t *testing.T) {
+	l := lexer.New("x = 0x10..0x12")
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	re, ok := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.RangeExpression)
+	if !ok {
+		t.Fatalf("expected range expression, got %T", program.Statements[0].(*ast.AssignmentStatement).Value)
+	}
+	if start := re.Start.(*ast.IntegerLiteral).Value; start != 16 {
+		t.Errorf("expected start 16, got %d", start)
+	}
+}
+
+func TestParseStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = "a\nb\tc\rd"`, "a\nb\tc\rd"},
+		{`x = "\"quoted\" \\ \/"`, `"quoted" \ /`},
+		{`x = "\b\f"`, "\b\f"},
+		{`x = "café"`, "café"},
+		{`x = "\u{1F600}"`, "😀"},
+		{`x = "\u00e9\uD83D\uDE00"`, "é😀"},
+		{`x = "😀"`, "😀"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%s: parser errors: %v", tt.input, p.Errors())
+		}
+		lit, ok := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.StringLiteral)
+		if !ok || lit.Value != tt.expected {
+			t.Errorf("%s: expected %q, got %#v", tt.input, tt.expected, program.Statements[0].(*ast.AssignmentStatement).Value)
+		}
+	}
+}
+
+func TestParseMalformedStringEscapes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`x = "\q"`, `unknown escape \q`},
+		{`x = "\u12"`, `malformed unicode escape \u12`},
+		{`x = "\u{110000}"`, "not a valid Unicode code point"},
+		{`x = "\u{}"`, "expected 1 to 6 hex digits"},
+		{`x = "\uD800"`, "unpaired UTF-16 surrogate"},
+		{`x = "\uDE00\uD83D"`, "unpaired UTF-16 surrogate"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%s: expected an escape error", tt.input)
+			continue
+		}
+		if !strings.Contains(p.Errors()[0], tt.want) || !strings.Contains(p.Errors()[0], "1:6") {
+			t.Errorf("%s: unexpected error: %v", tt.input, p.Errors())
+		}
+	}
+}
+
+func TestParseCommentsAndDocs(t *testing.T) {
+	input := `
+/* a block comment
+   spanning lines */
+/// The service name
+name = "api" /* trailing */
+//// not a doc comment
+replicas = 3
+/// Server settings
+/// second line
+server {
+  /// Listener port
+  port = 8080
+}
+`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+
+	docs := []string{"The service name", "", "Server settings\nsecond line"}
+	for i, want := range docs {
+		stmt := program.Statements[i].(*ast.AssignmentStatement)
+		if stmt.Doc != want {
+			t.Errorf("statement %d: expected doc %q, got %q", i, want, stmt.Doc)
+		}
+	}
+
+	obj := program.Statements[2].(*ast.AssignmentStatement).Value.(*ast.ObjectLiteral)
+	if obj.Docs["port"] != "Listener port" {
+		t.Errorf("expected property doc for port, got %q", obj.Docs["port"])
+	}
+}
+
+func TestParseObjectKeyPositions(t *testing.T) {
+	input := "server {\n  host = \"localhost\"\n  tmp := 1\n  port = 8080\n}"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	obj := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.ObjectLiteral)
+	if tok := obj.KeyTokens["port"]; tok.Line != 4 || tok.Literal != "port" {
+		t.Errorf("expected the port key token on line 4, got %+v", tok)
+	}
+	if _, ok := obj.KeyTokens["tmp"]; ok {
+		t.Errorf("local declarations shouldn't be recorded as property keys")
+	}
+}
+
+func TestParseTypeDeclarations(t *testing.T) {
+	input := `
+type Deployment {
+  name: string(1..63)
+  replicas: int(1..50)
+  env: "prod" | "staging" | "dev"
+  labels?: { app: string }
+  ports: [int(1..)]
+}
+schema Ratio = float(0..1) | null
+type = "web"
+deploy: Deployment = { name = "api" }
+users: { id: int } [
+  template { id }
+  1
+]
+`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(program.Statements) != 5 {
+		t.Fatalf("expected 5 statements, got %d", len(program.Statements))
+	}
+
+	want := []string{
+		`type Deployment { name: string(1..63), replicas: int(1..50), env: "prod" | "staging" | "dev", labels?: { app: string }, ports: [int(1..)] }`,
+		"schema Ratio = float(0..1) | null",
+	}
+	for i, w := range want {
+		if got := program.Statements[i].String(); got != w {
+			t.Errorf("statement %d: expected %s, got %s", i, w, got)
+		}
+	}
+
+	// 'type' followed by '=' is still an ordinary key
+	if s, ok := program.Statements[2].(*ast.AssignmentStatement); !ok || s.Name.Value != "type" {
+		t.Errorf("expected an assignment to type, got %s", program.Statements[2])
+	}
+	deploy := program.Statements[3].(*ast.AssignmentStatement)
+	if deploy.Type == nil || deploy.Type.String() != "Deployment" {
+		t.Errorf("expected deploy to be annotated with Deployment, got %v", deploy.Type)
+	}
+	users := program.Statements[4].(*ast.AssignmentStatement)
+	if _, ok := users.Value.(*ast.ArrayTemplate); !ok || users.Type == nil {
+		t.Errorf("expected an annotated array template, got %s", users)
+	}
+}
+
+func TestParseTypeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"type Port", "expected '{' or '=' after type Port"},
+		{"type A { name string }", "expected ':' and a type after field name"},
+		{"type A = int(1..x)", "expected a number as a type bound"},
+		{"type A = ,", "expected a type"},
+		{"x: int", "expected '=', '{' or '[' after the type of x"},
+		{"x = { y: int = \"s\" }", "1:8 — type annotations are only allowed at top level, not on y"},
+		{"x {\n  a = 1\n  port: int(1..65535) = 80\n}", "3:7 — type annotations are only allowed at top level, not on port"},
+		{"x { env: \"prod\" | \"dev\" = \"prod\" }", "only allowed at top level, not on env"},
+		{"x { db: Database { host = \"h\" } }", "only allowed at top level, not on db"},
+		{"assert )", "expected a condition after assert"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		if len(p.Errors()) == 0 || !strings.Contains(p.Errors()[0], tt.want) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.input, tt.want, p.Errors())
+		}
+	}
+}
+
+func TestParseAssertStatements(t *testing.T) {
+	input := `assert replicas > 0, "replicas must be positive"
+assert = "still a key"
+server {
+  port = 80
+  assert port < 1024, host = "localhost"
+  assert port > 0
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+
+	top, ok := program.Statements[0].(*ast.AssertStatement)
+	if !ok {
+		t.Fatalf("expected AssertStatement, got %T", program.Statements[0])
+	}
+	if got := top.String(); got != "assert (replicas > 0), replicas must be positive" {
+		t.Errorf("unexpected assert: %s", got)
+	}
+	if _, ok := program.Statements[1].(*ast.AssignmentStatement); !ok {
+		t.Errorf("expected 'assert = ...' to stay an assignment, got %T", program.Statements[1])
+	}
+
+	obj := program.Statements[2].(*ast.AssignmentStatement).Value.(*ast.ObjectLiteral)
+	if len(obj.Asserts) != 2 || obj.Asserts[0].Message != nil || obj.Asserts[1].Token.Line != 6 {
+		t.Fatalf("expected two message-less object asserts, got %v", obj.Asserts)
+	}
+	if strings.Join(obj.Keys, ",") != "port,host" {
+		t.Errorf("expected the property after an assert to be kept, got keys %v", obj.Keys)
+	}
+}
+
+func TestParseUnterminatedBlockComment(t *testing.T) {
+	l := lexer.New("x = 1\n/* never closed\ny = 2")
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || !strings.Contains(p.Errors()[0], "2:1") || !strings.Contains(p.Errors()[0], "no closing */") {
+		t.Fatalf("expected unterminated block comment error, got %v", p.Errors())
+	}
+}
+
+func TestParseCallExpression(t *testing.T) {
+	l := lexer.New(`data = load("users.csv")`)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	call, ok := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("expected CallExpression, got %T", program.Statements[0].(*ast.AssignmentStatement).Value)
+	}
+	if call.String() != "load(users.csv)" {
+		t.Errorf("unexpected call: %s", call.String())
+	}
+
+	// A space before '(' keeps the name and the group as separate row values
+	l = lexer.New("rows [\n  template { name, total }\n  label (1 + 2)\n]")
+	p = New(l)
+	program = p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	at := program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.ArrayTemplate)
+	if len(at.Rows) != 1 || len(at.Rows[0]) != 2 {
+		t.Fatalf("expected one row with two values, got %v", at.Rows)
+	}
+}
+
+func TestParseIncludeModifiers(t *testing.T) {
+	l := lexer.New("include \"services\" keyed as catalog.services\nconfig {\n  include \"db.jsson\" as database\n}")
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	inc := program.Statements[0].(*ast.IncludeStatement)
+	if !inc.Keyed || strings.Join(inc.Mount, ".") != "catalog.services" {
+		t.Fatalf("unexpected include modifiers: %s", inc.String())
+	}
+	obj := program.Statements[1].(*ast.AssignmentStatement).Value.(*ast.ObjectLiteral)
+	if len(obj.Includes) != 1 || obj.Includes[0].Mount[0] != "database" {
+		t.Fatalf("expected include inside object body, got %v", obj.Includes)
+	}
+
+	p = New(lexer.New(`include "db.jsson" as 42`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected an error for a missing mount path")
+	}
+}
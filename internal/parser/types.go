@@ -0,0 +1,222 @@
+package parser
+
+import (
+	"jsson/internal/ast"
+	ie "jsson/internal/errors"
+	"jsson/internal/token"
+)
+
+// parseTypeDeclaration parses 'type Name { fields }' or 'type Name = type'
+func (p *Parser) parseTypeDeclaration() ast.Statement {
+	decl := &ast.TypeDeclaration{Token: p.curToken, Doc: p.curToken.Doc}
+	p.nextToken() // consume type
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	switch p.peekToken.Type {
+	case token.LBRACE:
+		p.nextToken()
+		decl.Type = p.parseObjectType()
+	case token.ASSIGN:
+		p.nextToken() // consume name
+		p.nextToken() // consume =
+		decl.Type = p.parseTypeExpression()
+	default:
+		p.addError(ie.TypeBodyExpected(decl.Name.Value))
+		return nil
+	}
+	if decl.Type == nil {
+		return nil
+	}
+	return decl
+}
+
+// parseAnnotatedStatement parses an assignment with a type annotation:
+// 'name: Type = value', 'name: Type { ... }' or 'name: Row [ template ... ]'
+func (p *Parser) parseAnnotatedStatement() ast.Statement {
+	stmt := &ast.AssignmentStatement{Token: p.curToken, Doc: p.curToken.Doc}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	p.nextToken() // consume IDENT
+	p.nextToken() // consume :
+	stmt.Type = p.parseTypeExpression()
+	if stmt.Type == nil {
+		return nil
+	}
+
+	switch p.peekToken.Type {
+	case token.ASSIGN:
+		p.nextToken()
+		p.nextToken()
+		stmt.Value = p.parseExpression(LOWEST)
+	case token.LBRACE:
+		p.nextToken()
+		stmt.Value = p.parseExpression(LOWEST)
+	case token.LBRACKET:
+		p.nextToken()
+		stmt.Value = p.parseArrayTemplate()
+	default:
+		p.nextToken()
+		p.addError(ie.AnnotatedValueExpected(stmt.Name.Value))
+		return nil
+	}
+	return stmt
+}
+
+// parseTypeExpression parses a type, including unions: A | B | C. It ends on
+// the type's last token.
+func (p *Parser) parseTypeExpression() ast.TypeExpression {
+	first := p.parseTypeTerm()
+	if first == nil || p.peekToken.Type != token.PIPE {
+		return first
+	}
+
+	union := &ast.UnionType{Token: p.curToken, Options: []ast.TypeExpression{first}}
+	for p.peekToken.Type == token.PIPE {
+		p.nextToken() // move to |
+		p.nextToken() // consume |
+		opt := p.parseTypeTerm()
+		if opt == nil {
+			return nil
+		}
+		union.Options = append(union.Options, opt)
+	}
+	return union
+}
+
+func (p *Parser) parseTypeTerm() ast.TypeExpression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		named := &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+		if p.peekToken.Type == token.LPAREN {
+			p.nextToken()
+			if !p.parseTypeBounds(named) {
+				return nil
+			}
+		}
+		return named
+	case token.NULL:
+		return &ast.NamedType{Token: p.curToken, Name: "null"}
+	case token.STRING, token.INT, token.FLOAT, token.MINUS, token.TRUE, token.FALSE:
+		lit := &ast.LiteralType{Token: p.curToken}
+		lit.Value = p.parsePrefix()
+		switch lit.Value.(type) {
+		case *ast.StringLiteral, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.BooleanLiteral:
+			return lit
+		}
+		p.addError(ie.TypeExpected(lit.Token.Literal))
+		return nil
+	case token.LBRACKET:
+		arr := &ast.ArrayType{Token: p.curToken}
+		p.nextToken() // consume [
+		arr.Elem = p.parseTypeExpression()
+		if arr.Elem == nil {
+			return nil
+		}
+		if p.peekToken.Type != token.RBRACKET {
+			p.nextToken()
+			p.addError(ie.ExpectedToken("']'", p.curToken.Literal))
+			return nil
+		}
+		p.nextToken()
+		return arr
+	case token.LBRACE:
+		return p.parseObjectType()
+	case token.LPAREN:
+		p.nextToken() // consume (
+		inner := p.parseTypeExpression()
+		if inner == nil {
+			return nil
+		}
+		if p.peekToken.Type != token.RPAREN {
+			p.nextToken()
+			p.addError(ie.MissingClosingParen())
+			return nil
+		}
+		p.nextToken()
+		return inner
+	}
+	p.addError(ie.TypeExpected(p.curToken.Literal))
+	return nil
+}
+
+// parseTypeBounds parses '(min..max)' after a type name; either side may be
+// left open. It starts on '(' and ends on ')'.
+func (p *Parser) parseTypeBounds(named *ast.NamedType) bool {
+	named.Bounded = true
+	p.nextToken() // consume (
+	if p.curToken.Type != token.RANGE {
+		if named.Min = p.parseTypeBound(); named.Min == nil {
+			return false
+		}
+		p.nextToken()
+	}
+	if p.curToken.Type != token.RANGE {
+		p.addError(ie.ExpectedToken("'..'", p.curToken.Literal))
+		return false
+	}
+	if p.peekToken.Type != token.RPAREN {
+		p.nextToken()
+		if named.Max = p.parseTypeBound(); named.Max == nil {
+			return false
+		}
+	}
+	if p.peekToken.Type != token.RPAREN {
+		p.nextToken()
+		p.addError(ie.MissingClosingParen())
+		return false
+	}
+	p.nextToken()
+	return true
+}
+
+func (p *Parser) parseTypeBound() ast.Expression {
+	if p.curToken.Type == token.INT || p.curToken.Type == token.FLOAT || p.curToken.Type == token.MINUS {
+		switch bound := p.parsePrefix().(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral:
+			return bound
+		}
+	}
+	p.addError(ie.TypeBoundExpected(p.curToken.Literal))
+	return nil
+}
+
+// parseObjectType parses '{ name: type, optional?: type }'. It starts on '{'
+// and ends on '}'.
+func (p *Parser) parseObjectType() ast.TypeExpression {
+	obj := &ast.ObjectType{Token: p.curToken}
+	p.nextToken() // consume {
+
+	for p.curToken.Type != token.RBRACE && p.curToken.Type != token.EOF {
+		if p.curToken.Type == token.COMMA {
+			p.nextToken()
+			continue
+		}
+		if p.curToken.Type != token.IDENT {
+			p.addError(ie.ExpectedToken("a field name", p.curToken.Literal))
+			return nil
+		}
+
+		field := &ast.TypeField{Token: p.curToken, Name: p.curToken.Literal}
+		if p.peekToken.Type == token.QUESTION {
+			p.nextToken()
+			field.Optional = true
+		}
+		if p.peekToken.Type != token.COLON {
+			p.addError(ie.FieldTypeExpected(field.Name))
+			return nil
+		}
+		p.nextToken() // move to :
+		p.nextToken() // consume :
+		if field.Type = p.parseTypeExpression(); field.Type == nil {
+			return nil
+		}
+		obj.Fields = append(obj.Fields, field)
+		p.nextToken() // consume the type's last token
+	}
+
+	if p.curToken.Type != token.RBRACE {
+		p.addError(ie.MissingClosingBrace())
+		return nil
+	}
+	return obj
+}
@@ -7,6 +7,7 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int
+	Doc     string // '///' doc comment lines directly preceding the token
 }
 
 const (
@@ -23,9 +24,12 @@ const (
 
 	// Operators
 	ASSIGN   = "="
+	BANG     = "!"
 	DECLARE  = ":=" // Variable declaration
 	COLON    = ":"
 	QUESTION = "?"
+	NULLISH  = "??" // Null-coalescing
+	OPTCHAIN = "?." // Optional member access
 	EQ       = "=="
 	NEQ      = "!="
 	LT       = "<"
@@ -41,6 +45,7 @@ const (
 	MODULO   = "%"
 	LAND     = "&&" // Logical AND
 	LOR      = "||" // Logical OR
+	PIPE     = "|"  // Type union
 
 	// Delimiters
 	COMMA    = ","
@@ -54,6 +59,7 @@ const (
 	// Keywords
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
+	NULL     = "NULL"
 	TEMPLATE = "TEMPLATE"
 	MAP      = "MAP"
 	INCLUDE  = "INCLUDE"
@@ -63,6 +69,7 @@ const (
 var keywords = map[string]TokenType{
 	"true":     TRUE,
 	"false":    FALSE,
+	"null":     NULL,
 	"template": TEMPLATE,
 	"map":      MAP,
 	"include":  INCLUDE,
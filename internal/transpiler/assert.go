@@ -0,0 +1,29 @@
+package transpiler
+
+import (
+	"context"
+	"jsson/internal/ast"
+	ie "jsson/internal/errors"
+)
+
+// evalAssert fails the build when the assertion's condition doesn't hold.
+// Conditions follow the same truthiness as the ?: operator.
+func (t *Transpiler) evalAssert(ctx context.Context, a *ast.AssertStatement, scope *Scope) error {
+	cond, err := t.evalExpression(ctx, a.Condition, scope)
+	if err != nil {
+		return err
+	}
+	if t.isTruthy(cond) {
+		return nil
+	}
+
+	message := a.Condition.String()
+	if a.Message != nil {
+		val, err := t.evalExpression(ctx, a.Message, scope)
+		if err != nil {
+			return err
+		}
+		message = formatValue(val)
+	}
+	return t.errfNodeMsg(a, ie.AssertionFailed(message))
+}
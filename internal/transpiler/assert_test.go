@@ -0,0 +1,70 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssert_PassingAssertionsLeaveNoTrace(t *testing.T) {
+	input := `replicas = 3
+assert replicas > 0, "replicas must be positive"
+server {
+  limit := 1024
+  port = 8080
+  assert port >= limit
+}`
+	out, err := newTestTranspiler(t, input).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	if got := strings.Join(strings.Fields(string(out)), " "); got != `{ "replicas": 3, "server": { "port": 8080 } }` {
+		t.Errorf("Unexpected output: %s", got)
+	}
+}
+
+func TestAssert_FailuresArePositioned(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			"top level",
+			"replicas = 0\nassert replicas > 0, \"replicas must be positive\"",
+			[]string{"main.jsson:2:", "assertion failed: replicas must be positive"},
+		},
+		{
+			"no message",
+			"x = 1\nassert x == 2",
+			[]string{"main.jsson:2:", "assertion failed: (x == 2)"},
+		},
+		{
+			"object",
+			"server {\n  port = 80\n  assert port >= 1024, `port ${port} is privileged`\n}",
+			[]string{"main.jsson:3:", "port 80 is privileged"},
+		},
+		{
+			"map body",
+			"deployments [\n  template { env, replicas }\n  map (d) = {\n    env = d.env\n    replicas = d.replicas\n    assert env != \"prod\" || replicas >= 3, `${env} needs 3 replicas`\n  }\n  \"dev\", 1\n  \"prod\", 2\n]",
+			[]string{"main.jsson:6:", "prod needs 3 replicas"},
+		},
+		{
+			"order",
+			"assert ready == true\nready = true",
+			[]string{"main.jsson:1:", "(ready == true)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestTranspiler(t, tt.input, withSourceFile("main.jsson")).Transpile()
+			if err == nil {
+				t.Fatal("Expected the assertion to fail the build")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected %q in %v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package transpiler
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// rootTypeName names the type of the whole document in generated code
+const rootTypeName = "Config"
+
+// typeModel names the object shapes of an inferred document for the code
+// generators. Every object position gets a type of its own, named after the
+// key it sits at; types are listed after the types they use, the root last.
+type typeModel struct {
+	root  *shape
+	types []*recordType
+	names map[*shape]string
+	taken map[string]bool
+}
+
+// recordType is one named object shape
+type recordType struct {
+	name  string
+	path  string            // dotted output path, for doc comments
+	docs  map[string]string // nil inside arrays, whose elements have no path
+	shape *shape
+}
+
+// recordField is one key of a record type
+type recordField struct {
+	key      string
+	doc      string
+	optional bool // missing from some of the objects seen at this position
+	shape    *shape
+}
+
+// inferTypeModel infers and names the types of an evaluated document.
+// reserved lists names the target language already uses, which types avoid.
+func (t *Transpiler) inferTypeModel(ctx context.Context, root map[string]interface{}, reserved ...string) (*typeModel, error) {
+	in := &inferrer{ctx: ctx}
+	s := newShape()
+	if err := in.add(s, root); err != nil {
+		return nil, err
+	}
+	m := &typeModel{root: s, names: make(map[*shape]string), taken: make(map[string]bool)}
+	for _, name := range reserved {
+		m.taken[name] = true
+	}
+	m.name(s, rootTypeName, "", "", t.collectDocs())
+	return m, nil
+}
+
+// name names every object shape at or below s. hint is the name suggested by
+// the key s sits at and parent the name of the enclosing type.
+func (m *typeModel) name(s *shape, hint, parent, path string, docs map[string]string) {
+	if s.types["object"] {
+		name := m.unique(hint, parent)
+		m.names[s] = name
+		// Prefixing with the root's name wouldn't say anything
+		prefix := name
+		if s == m.root {
+			prefix = ""
+		}
+		for _, k := range s.keys() {
+			m.name(s.props[k], pascalIdent(k), prefix, joinPath(path, k), docs)
+		}
+		m.types = append(m.types, &recordType{name: name, path: path, docs: docs, shape: s})
+	}
+	if s.items != nil {
+		m.name(s.items, singular(hint), parent, "", nil)
+	}
+}
+
+// unique returns hint, or parent+hint when hint is taken, or hint with the
+// first free number appended
+func (m *typeModel) unique(hint, parent string) string {
+	if hint == "" {
+		hint = "Item"
+	}
+	name := hint
+	if m.taken[name] && parent != "" {
+		name = parent + hint
+	}
+	for i := 2; m.taken[name]; i++ {
+		name = hint + strconv.Itoa(i)
+	}
+	m.taken[name] = true
+	return name
+}
+
+// fields returns the keys of r in order
+func (r *recordType) fields() []recordField {
+	keys := r.shape.keys()
+	fields := make([]recordField, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, recordField{
+			key:      k,
+			doc:      r.docs[joinPath(r.path, k)],
+			optional: r.shape.keyCount[k] < r.shape.objects,
+			shape:    r.shape.props[k],
+		})
+	}
+	return fields
+}
+
+// keys returns the keys seen at an object position, sorted
+func (s *shape) keys() []string {
+	keys := make([]string, 0, len(s.props))
+	for k := range s.props {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// valueTypes returns the non-null types seen at s, and whether null was seen
+// too. Most languages spell a nullable value differently from a union.
+func (s *shape) valueTypes() (types []string, nullable bool) {
+	for _, typ := range s.typeList() {
+		if typ == "null" {
+			nullable = true
+			continue
+		}
+		types = append(types, typ)
+	}
+	return types, nullable
+}
+
+// identWords splits a key into words at punctuation, spaces and case changes,
+// so "my-key", "my_key" and "myKey" all give "my" and "key" in some case
+func identWords(key string) []string {
+	var words []string
+	var cur []rune
+	rs := []rune(key)
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(cur) > 0 {
+				words = append(words, string(cur))
+				cur = nil
+			}
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			// "myKey" splits before K, "HTTPServer" before S
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(cur))
+				cur = nil
+			}
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+	return words
+}
+
+// pascalIdent turns a key into a PascalCase identifier: "my-key" gives MyKey.
+// Identifiers can't start with a digit, so those get an X in front.
+func pascalIdent(key string) string {
+	return digitSafe(joinWords(identWords(key)), "X")
+}
+
+// camelIdent turns a key into a camelCase identifier: "my-key" gives myKey
+func camelIdent(key string) string {
+	words := identWords(key)
+	if len(words) == 0 {
+		return ""
+	}
+	return digitSafe(strings.ToLower(words[0])+joinWords(words[1:]), "x")
+}
+
+// joinWords joins words with the first letter of each in upper case
+func joinWords(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		rs := []rune(w)
+		b.WriteRune(unicode.ToUpper(rs[0]))
+		b.WriteString(string(rs[1:]))
+	}
+	return b.String()
+}
+
+// snakeIdent turns a key into a snake_case identifier: "myKey" gives my_key
+func snakeIdent(key string) string {
+	words := identWords(key)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return digitSafe(strings.Join(words, "_"), "x")
+}
+
+func digitSafe(ident, prefix string) string {
+	if ident != "" && unicode.IsDigit([]rune(ident)[0]) {
+		return prefix + ident
+	}
+	return ident
+}
+
+// singular names the elements of an array from the array's name: Users gives
+// User, Entries gives Entry, and anything that doesn't end in a plain plural
+// gets Item appended
+func singular(name string) string {
+	switch {
+	case name == "":
+		return ""
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "is"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name + "Item"
+}
+
+// uniqueIdent returns ident, with the first free number appended if an
+// earlier key already turned into the same identifier
+func uniqueIdent(taken map[string]bool, ident string) string {
+	name := ident
+	for i := 2; taken[name]; i++ {
+		name = ident + strconv.Itoa(i)
+	}
+	taken[name] = true
+	return name
+}
@@ -0,0 +1,65 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+// generate transpiles input with a code generator; files are served to
+// include and load() from memory
+func generate(t *testing.T, input string, files map[string]string, setup func(*Transpiler), gen func(*Transpiler) ([]byte, error)) string {
+	t.Helper()
+	opts := []func(*Transpiler){func(tr *Transpiler) { tr.SetResolver(NewMapResolver(files)) }}
+	if setup != nil {
+		opts = append(opts, setup)
+	}
+	out, err := gen(newTestTranspiler(t, input, opts...))
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	return string(out)
+}
+
+func TestCodegen_Identifiers(t *testing.T) {
+	tests := []struct {
+		key, pascal, camel, snake string
+	}{
+		{"name", "Name", "name", "name"},
+		{"my-key", "MyKey", "myKey", "my_key"},
+		{"appName", "AppName", "appName", "app_name"},
+		{"HTTPServer", "HTTPServer", "httpServer", "http_server"},
+		{"max conns", "MaxConns", "maxConns", "max_conns"},
+		{"2fa", "X2fa", "x2fa", "x2fa"},
+		{"my-xray", "MyXray", "myXray", "my_xray"},
+	}
+	for _, tt := range tests {
+		if got := pascalIdent(tt.key); got != tt.pascal {
+			t.Errorf("pascalIdent(%q) = %q, want %q", tt.key, got, tt.pascal)
+		}
+		if got := camelIdent(tt.key); got != tt.camel {
+			t.Errorf("camelIdent(%q) = %q, want %q", tt.key, got, tt.camel)
+		}
+		if got := snakeIdent(tt.key); got != tt.snake {
+			t.Errorf("snakeIdent(%q) = %q, want %q", tt.key, got, tt.snake)
+		}
+	}
+}
+
+func TestCodegen_TypeNames(t *testing.T) {
+	for name, want := range map[string]string{
+		"Users": "User", "Entries": "Entry", "Boxes": "Box", "Status": "StatusItem", "Data": "DataItem",
+	} {
+		if got := singular(name); got != want {
+			t.Errorf("singular(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	// Keys are named in order, so admin.user claims User before the users elements
+	input := "users = [ { id = 1 } ]\nadmin { user { id = 1 } }\nconfig { debug = true }"
+	out := generate(t, input, nil, func(tr *Transpiler) { tr.SetTypeScriptMode(TypeScriptDeclarations) }, (*Transpiler).TranspileToTypeScript)
+	for _, want := range []string{"interface User {", "interface User2 {", "interface Config2 {", "interface Config {", "users: User2[];"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
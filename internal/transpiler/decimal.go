@@ -0,0 +1,186 @@
+package transpiler
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDecimalScale caps the fractional digits kept for results that have no
+// finite decimal expansion (for example 1.0 / 3)
+const maxDecimalScale = 34
+
+// Decimal is an exact decimal number. When decimal mode is enabled, float
+// literals evaluate to Decimal instead of float64 so arithmetic such as
+// 0.1 + 0.2 yields exactly 0.3 and prices keep their written precision.
+type Decimal struct {
+	rat   *big.Rat
+	scale int // fractional digits to print, taken from the literal
+}
+
+// NewDecimal parses a decimal literal such as "19.99", "-0.5" or "1.5e3"
+func NewDecimal(literal string) (Decimal, bool) {
+	clean := strings.ReplaceAll(literal, "_", "")
+	r, ok := new(big.Rat).SetString(clean)
+	if !ok {
+		return Decimal{}, false
+	}
+	return Decimal{rat: r, scale: literalScale(clean)}, true
+}
+
+// decimalFromInt converts an integer operand to a Decimal with no fractional digits
+func decimalFromInt(i int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(i)}
+}
+
+// decimalFromFloat converts a float operand through its shortest decimal
+// spelling, so a loaded 0.1 is 0.1 and not the binary value nearest to it.
+// Infinities and NaN have no decimal value.
+func decimalFromFloat(f float64) (Decimal, bool) {
+	return NewDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// literalScale counts the fractional digits written in a literal, accounting for exponents
+func literalScale(lit string) int {
+	mantissa, exp := lit, 0
+	if i := strings.IndexAny(lit, "eE"); i >= 0 {
+		mantissa = lit[:i]
+		var e big.Int
+		if _, ok := e.SetString(lit[i+1:], 10); ok && e.IsInt64() {
+			exp = int(e.Int64())
+		}
+	}
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+	}
+	scale -= exp
+	if scale < 0 {
+		return 0
+	}
+	return scale
+}
+
+// exactScale returns the number of fractional digits needed to print the value
+// exactly, or maxDecimalScale when the expansion never terminates
+func (d Decimal) exactScale() int {
+	denom := new(big.Int).Set(d.rat.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	twos, fives := 0, 0
+	mod := new(big.Int)
+	for {
+		if mod.Mod(denom, two).Sign() != 0 {
+			break
+		}
+		denom.Quo(denom, two)
+		twos++
+	}
+	for {
+		if mod.Mod(denom, five).Sign() != 0 {
+			break
+		}
+		denom.Quo(denom, five)
+		fives++
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return maxDecimalScale
+	}
+	if twos > fives {
+		return twos
+	}
+	return fives
+}
+
+func (d Decimal) withScale(scale int) Decimal {
+	if exact := d.exactScale(); exact > scale {
+		scale = exact
+	}
+	if scale > maxDecimalScale {
+		scale = maxDecimalScale
+	}
+	d.scale = scale
+	return d
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.rat, o.rat)}.withScale(max(d.scale, o.scale))
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.rat, o.rat)}.withScale(max(d.scale, o.scale))
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.rat, o.rat)}.withScale(d.scale + o.scale)
+}
+
+// Quo divides d by o; the caller must rule out a zero divisor
+func (d Decimal) Quo(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Quo(d.rat, o.rat)}.withScale(max(d.scale, o.scale))
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.rat), scale: d.scale}
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat.Cmp(o.rat)
+}
+
+func (d Decimal) IsZero() bool {
+	return d.rat.Sign() == 0
+}
+
+// String prints the value with its scale, e.g. 0.30 or 19.99
+func (d Decimal) String() string {
+	s := d.rat.FloatString(d.scale)
+	if d.scale == maxDecimalScale && strings.Contains(s, ".") {
+		// Non-terminating result: drop the padding but keep one fractional digit
+		s = strings.TrimRight(s, "0")
+		if strings.HasSuffix(s, ".") {
+			s += "0"
+		}
+	}
+	return s
+}
+
+// MarshalJSON writes the decimal as a bare JSON number without going through float64
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// MarshalYAML writes the decimal as a plain YAML number
+func (d Decimal) MarshalYAML() (interface{}, error) {
+	tag := "!!float"
+	if d.scale == 0 {
+		tag = "!!int"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: d.String()}, nil
+}
+
+// MarshalTOML writes the decimal as a bare TOML number
+func (d Decimal) MarshalTOML() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// toDecimal converts a numeric operand to a Decimal
+func toDecimal(val interface{}) (Decimal, bool) {
+	switch v := val.(type) {
+	case Decimal:
+		return v, true
+	case int64:
+		return decimalFromInt(v), true
+	case int:
+		return decimalFromInt(int64(v)), true
+	case float64:
+		return decimalFromFloat(v)
+	}
+	return Decimal{}, false
+}
+
+func isDecimal(val interface{}) bool {
+	_, ok := val.(Decimal)
+	return ok
+}
@@ -0,0 +1,121 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+// withDecimalMode is a newTestTranspiler option that turns on exact decimals
+func withDecimalMode(tr *Transpiler) {
+	tr.SetDecimalMode(true)
+}
+
+func TestDecimal_ExactArithmeticInJSON(t *testing.T) {
+	input := `
+price := 19.99
+total = 0.1 + 0.2
+tax = price * 0.08
+discounted = price - 5
+third = 1.0 / 3
+negative = -0.50
+equal = 0.1 + 0.2 == 0.3
+`
+	output, err := newTestTranspiler(t, input, withDecimalMode).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+
+	out := string(output)
+	for _, want := range []string{
+		`"total": 0.3`,
+		`"tax": 1.5992`,
+		`"discounted": 14.99`,
+		`"third": 0.3333333333333333333333333333333333`,
+		`"negative": -0.50`,
+		`"equal": true`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %s, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDecimal_ExactInYAMLAndTOML(t *testing.T) {
+	input := `
+total = 0.1 + 0.2
+prices = [ 1.10, 2.20 ]
+`
+	yamlOut, err := newTestTranspiler(t, input, withDecimalMode).TranspileToYAML()
+	if err != nil {
+		t.Fatalf("YAML transpile error: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "total: 0.3\n") || !strings.Contains(string(yamlOut), "- 1.10\n") {
+		t.Errorf("Unexpected YAML output:\n%s", yamlOut)
+	}
+
+	tomlOut, err := newTestTranspiler(t, input, withDecimalMode).TranspileToTOML()
+	if err != nil {
+		t.Fatalf("TOML transpile error: %v", err)
+	}
+	if !strings.Contains(string(tomlOut), "total = 0.3\n") || !strings.Contains(string(tomlOut), "prices = [1.10, 2.20]") {
+		t.Errorf("Unexpected TOML output:\n%s", tomlOut)
+	}
+}
+
+func TestDecimal_LoadedFloatsMixWithLiterals(t *testing.T) {
+	files := map[string]string{"x.yaml": "p: 0.1\n", "x.toml": "p = 0.1\n"}
+	input := "fromYAML = load(\"x.yaml\").p + 0.2\nfromTOML = load(\"x.toml\").p * 3"
+	output, err := newTestTranspiler(t, input, withDecimalMode, func(tr *Transpiler) { tr.SetResolver(NewMapResolver(files)) }).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+
+	out := string(output)
+	for _, want := range []string{`"fromYAML": 0.3`, `"fromTOML": 0.3`} {
+		if !strings.Contains(out, want+",") && !strings.Contains(out, want+"\n") {
+			t.Errorf("Expected output to contain %s, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDecimal_DivisionByZero(t *testing.T) {
+	_, err := newTestTranspiler(t, "x = 1.5 / 0", withDecimalMode).Transpile()
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Fatalf("Expected division by zero error, got: %v", err)
+	}
+}
+
+func TestArithmetic_IntegerOverflowIsReported(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"x = 9223372036854775807 + 1", "9223372036854775807 + 1"},
+		{"x = 0 - 9223372036854775807 - 2", "-9223372036854775807 - 2"},
+		{"x = 4611686018427387904 * 2", "4611686018427387904 * 2"},
+		{"big := 0 - 9223372036854775807 - 1\nx = big / -1", "-9223372036854775808 / -1"},
+		{"big := 0 - 9223372036854775807 - 1\nx = -big", "-(-9223372036854775808)"},
+	}
+
+	for _, tt := range tests {
+		_, err := newTestTranspiler(t, tt.input).Transpile()
+		if err == nil {
+			t.Errorf("Expected overflow error for %q", tt.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), "integer overflow: "+tt.want) {
+			t.Errorf("Unexpected error for %q: %v", tt.input, err)
+		}
+	}
+}
+
+func TestRange_NearMaxInt64Terminates(t *testing.T) {
+	input := "x = [ 9223372036854775805..9223372036854775807 ]"
+	output, err := newTestTranspiler(t, input).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	if strings.Count(string(output), "922337203685477580") != 3 {
+		t.Errorf("Expected exactly 3 values, got:\n%s", output)
+	}
+}
@@ -0,0 +1,168 @@
+package transpiler
+
+import (
+	"bufio"
+	"bytes"
+	"jsson/internal/ast"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// collectDocs gathers '///' doc comments from the program, keyed by the dotted
+// output path of the key they describe (e.g. "server.port"). Docs are found
+// statically through nested object literals and included files; generated
+// values carry none.
+func (t *Transpiler) collectDocs() map[string]string {
+	docs := make(map[string]string)
+	for _, stmt := range t.program.Statements {
+		switch s := stmt.(type) {
+		case *ast.AssignmentStatement:
+			if s.Doc != "" {
+				docs[s.Name.Value] = s.Doc
+			}
+			collectObjectDocs(s.Value, s.Name.Value, docs)
+		case *ast.IncludeStatement:
+			t.collectIncludeDocs(s, docs)
+		}
+	}
+	return docs
+}
+
+// collectIncludeDocs adds the docs of the files an include read, under the
+// path their output is mounted at. Keys documented in the including file keep
+// their own docs.
+func (t *Transpiler) collectIncludeDocs(s *ast.IncludeStatement, docs map[string]string) {
+	paths, err := t.resolveIncludePaths(s)
+	if err != nil {
+		return
+	}
+	for _, includeAbs := range paths {
+		prefix := strings.Join(s.Mount, ".")
+		if s.Keyed {
+			prefix = joinPath(prefix, strings.TrimSuffix(filepath.Base(includeAbs), filepath.Ext(includeAbs)))
+		}
+		for path, doc := range t.includeDocs[includeAbs] {
+			path = joinPath(prefix, path)
+			if _, ok := docs[path]; !ok {
+				docs[path] = doc
+			}
+		}
+	}
+}
+
+func collectObjectDocs(expr ast.Expression, path string, docs map[string]string) {
+	obj, ok := expr.(*ast.ObjectLiteral)
+	if !ok {
+		return
+	}
+	for _, key := range obj.Keys {
+		keyPath := joinPath(path, key)
+		if doc := obj.Docs[key]; doc != "" {
+			docs[keyPath] = doc
+		}
+		collectObjectDocs(obj.Properties[key], keyPath, docs)
+	}
+}
+
+// attachYAMLDocs sets doc comments as head comments on the matching mapping keys
+func attachYAMLDocs(node *yaml.Node, path string, docs map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, keyNode.Value)
+		if doc, ok := docs[keyPath]; ok {
+			keyNode.HeadComment = doc
+		}
+		attachYAMLDocs(valNode, keyPath, docs)
+	}
+}
+
+// insertTOMLDocs writes doc comments above the TOML keys and table headers
+// they describe. The encoder output is line based: one key or header per line.
+func insertTOMLDocs(data []byte, docs map[string]string) []byte {
+	var out bytes.Buffer
+	table := ""
+	seenTables := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		var path string
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			table = tomlKeyPath(strings.TrimSuffix(strings.TrimPrefix(trimmed, "[["), "]]"))
+			path = table
+		case strings.HasPrefix(trimmed, "["):
+			table = tomlKeyPath(strings.TrimSuffix(strings.TrimPrefix(trimmed, "["), "]"))
+			path = table
+		default:
+			if eq := tomlKeyEnd(trimmed); eq > 0 {
+				path = joinPath(table, tomlKeyPath(strings.TrimSpace(trimmed[:eq])))
+			}
+		}
+
+		// Array-of-tables headers repeat; document the array once
+		if doc, ok := docs[path]; ok && path != "" && !seenTables[path] {
+			seenTables[path] = true
+			for _, docLine := range strings.Split(doc, "\n") {
+				out.WriteString(strings.TrimRight(indent+"# "+docLine, " "))
+				out.WriteByte('\n')
+			}
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// tomlKeyEnd returns the index of the '=' ending a key, skipping quoted keys
+func tomlKeyEnd(line string) int {
+	inQuote := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '=':
+			if !inQuote {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// tomlKeyPath turns a TOML dotted key (parts may be quoted) into a plain dotted path
+func tomlKeyPath(key string) string {
+	var parts []string
+	var cur strings.Builder
+	inQuote := false
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '\\' && inQuote && i+1 < len(key):
+			i++
+			cur.WriteByte(key[i])
+		case c == '"':
+			inQuote = !inQuote
+		case c == '.' && !inQuote:
+			parts = append(parts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	parts = append(parts, strings.TrimSpace(cur.String()))
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,109 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+const docsInput = `
+/// Public name
+name = "api"
+
+/// Network settings.
+/// Requires a restart.
+server {
+  /// Listener port
+  port = 8080
+  tls {
+    /// PEM file path
+    cert = "/etc/tls.pem"
+  }
+}
+`
+
+func TestDocs_YAMLComments(t *testing.T) {
+	output, err := newTestTranspiler(t, docsInput).TranspileToYAML()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	out := string(output)
+	for _, want := range []string{
+		"# Public name\nname: api\n",
+		"# Network settings.\n# Requires a restart.\nserver:\n",
+		"    # Listener port\n    port: 8080\n",
+		"        # PEM file path\n        cert: /etc/tls.pem\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected YAML to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDocs_TOMLComments(t *testing.T) {
+	output, err := newTestTranspiler(t, docsInput).TranspileToTOML()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	out := string(output)
+	for _, want := range []string{
+		"# Public name\nname = \"api\"\n",
+		"# Network settings.\n# Requires a restart.\n[server]\n",
+		"  # Listener port\n  port = 8080\n",
+		"    # PEM file path\n    cert = \"/etc/tls.pem\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected TOML to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDocs_TypeScriptJSDoc(t *testing.T) {
+	output, err := newTestTranspiler(t, docsInput).TranspileToTypeScript()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	out := string(output)
+	for _, want := range []string{
+		"/** Public name */\nexport const name",
+		"/**\n * Network settings.\n * Requires a restart.\n */\nexport const server",
+		"  /** Listener port */\n  port: 8080",
+		"    /** PEM file path */\n    cert:",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected TypeScript to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDocs_JSONUnchanged(t *testing.T) {
+	output, err := newTestTranspiler(t, docsInput).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	if strings.Contains(string(output), "Listener port") {
+		t.Errorf("JSON has no comments, but doc text leaked into output:\n%s", output)
+	}
+}
+
+func TestDocs_FromIncludedFiles(t *testing.T) {
+	files := map[string]string{
+		"inc.jsson": "/// inc doc\ninc = 1\n/// shadowed doc\nname = \"inc\"\nnested {\n  /// deep doc\n  x = 2\n}",
+		"db.jsson":  "/// host doc\nhost = \"h\"",
+	}
+	input := "include \"inc.jsson\"\ninclude \"db.jsson\" as database\n/// own doc\nname = \"main\""
+	output, err := newTestTranspiler(t, input, func(tr *Transpiler) { tr.SetResolver(NewMapResolver(files)) }).TranspileToYAML()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	out := string(output)
+	for _, want := range []string{
+		"# inc doc\ninc: 1\n",
+		"    # deep doc\n    x: 2\n",
+		"database:\n    # host doc\n    host: h\n",
+		"# own doc\nname: main\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected YAML to contain %q, got:\n%s", want, out)
+		}
+	}
+}
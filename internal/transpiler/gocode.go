@@ -0,0 +1,156 @@
+package transpiler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	ie "jsson/internal/errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultGoPackage is the package of generated Go code unless SetGoPackage says otherwise
+const defaultGoPackage = "config"
+
+// goInitialisms are words Go spells in one case, as in ID or URL
+var goInitialisms = map[string]bool{
+	"API": true, "CPU": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true, "YAML": true,
+}
+
+// SetGoPackage sets the package clause of TranspileToGo's output
+func (t *Transpiler) SetGoPackage(name string) {
+	t.goPackage = name
+}
+
+// TranspileToGo writes Go struct declarations with json tags inferred from
+// the evaluated document, ready to decode the JSON output into
+func (t *Transpiler) TranspileToGo() ([]byte, error) {
+	return t.TranspileToGoContext(context.Background())
+}
+
+// TranspileToGoContext is TranspileToGo that stops with ctx's error once ctx
+// is done
+func (t *Transpiler) TranspileToGoContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := t.inferTypeModel(ctx, root)
+	if err != nil {
+		return t.limitOutput(ctx, nil, err)
+	}
+
+	pkg := t.goPackage
+	if pkg == "" {
+		pkg = defaultGoPackage
+	}
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by jsson. DO NOT EDIT.\n\n")
+	buf.WriteString("package " + pkg + "\n")
+	for _, r := range m.types {
+		buf.WriteString(fmt.Sprintf("\ntype %s struct {\n", r.name))
+		names := make(map[string]bool)
+		for _, f := range r.fields() {
+			// An empty json tag falls back to the field name, so the key would
+			// be silently dropped on decode
+			if f.key == "" {
+				return t.limitOutput(ctx, nil, t.errMsg(ie.GoEmptyKey(r.name)))
+			}
+			writeLineComments(&buf, f.doc, "//")
+			typ := m.goType(f.shape, f.optional)
+			tag := f.key
+			if f.optional {
+				tag += ",omitempty"
+			}
+			buf.WriteString(fmt.Sprintf("%s %s %s\n", uniqueIdent(names, goFieldName(f.key)), typ, goTag(tag)))
+		}
+		buf.WriteString("}\n")
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return t.limitOutput(ctx, nil, t.errMsg(ie.GeneratedCodeInvalid("Go", err)))
+	}
+	return t.limitOutput(ctx, out, nil)
+}
+
+// writeLineComments writes doc as line comments starting with marker;
+// nothing is written for an empty doc
+func writeLineComments(buf *bytes.Buffer, doc, marker string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		buf.WriteString(strings.TrimRight(marker+" "+line, " ") + "\n")
+	}
+}
+
+// goType spells the type of a position. A value that is sometimes missing or
+// null becomes a pointer, unless its type already has a nil value.
+func (m *typeModel) goType(s *shape, optional bool) string {
+	types, nullable := s.valueTypes()
+	if len(types) != 1 {
+		// Mixed values, or only ever null
+		return "interface{}"
+	}
+	var typ string
+	switch types[0] {
+	case "object":
+		typ = m.names[s]
+	case "array":
+		if s.items == nil {
+			return "[]interface{}"
+		}
+		return "[]" + m.goType(s.items, false)
+	case "string":
+		typ = "string"
+	case "integer":
+		typ = "int64"
+	case "number":
+		typ = "float64"
+	case "boolean":
+		typ = "bool"
+	}
+	if optional || nullable {
+		return "*" + typ
+	}
+	return typ
+}
+
+// goFieldName turns a key into an exported field name, with initialisms in
+// upper case: "user_id" gives UserID. Names that can't start upper case, such
+// as "2fa" or "日本", get an X in front, since encoding/json skips unexported
+// fields.
+func goFieldName(key string) string {
+	words := identWords(key)
+	for i, w := range words {
+		if upper := strings.ToUpper(w); goInitialisms[upper] {
+			words[i] = upper
+		}
+	}
+	name := joinWords(words)
+	if name == "" {
+		return "Field"
+	}
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+		name = "X" + name
+	}
+	return name
+}
+
+// goTag writes a json struct tag, as a raw string unless the key holds a backquote
+func goTag(name string) string {
+	tag := "json:" + strconv.Quote(name)
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
@@ -0,0 +1,66 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGo_StructsFromValues(t *testing.T) {
+	input := `/// Service name
+name = "api"
+users = [
+  { id = 1, api_url = "a", email = "a@x.io" }
+  { id = 2, api_url = "b" }
+  { id = 3, api_url = "c", email = null, tags = [ "x", 1 ] }
+]
+ratio = 0.5
+nothing = null`
+	out := generate(t, input, nil, func(tr *Transpiler) { tr.SetGoPackage("app") }, (*Transpiler).TranspileToGo)
+
+	want := "// Code generated by jsson. DO NOT EDIT.\n\npackage app\n\n" +
+		"type User struct {\n" +
+		"\tAPIURL string        `json:\"api_url\"`\n" +
+		"\tEmail  *string       `json:\"email,omitempty\"`\n" +
+		"\tID     int64         `json:\"id\"`\n" +
+		"\tTags   []interface{} `json:\"tags,omitempty\"`\n" +
+		"}\n\n" +
+		"type Config struct {\n" +
+		"\t// Service name\n" +
+		"\tName    string      `json:\"name\"`\n" +
+		"\tNothing interface{} `json:\"nothing\"`\n" +
+		"\tRatio   float64     `json:\"ratio\"`\n" +
+		"\tUsers   []User      `json:\"users\"`\n" +
+		"}\n"
+	if out != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestGo_FieldNamesAndTags(t *testing.T) {
+	files := map[string]string{"data.json": "{\"my-key\": 1, \"myKey\": 2, \"2fa\": true, \"odd`key\": \"x\", \"limits\": {\"cpu\": 0.5}, \"日本\": 3}"}
+	out := generate(t, `data = load("data.json")`, files, nil, (*Transpiler).TranspileToGo)
+
+	for _, want := range []string{
+		"package config\n",
+		"X2fa   bool   `json:\"2fa\"`",
+		"MyKey  int64  `json:\"my-key\"`",
+		"MyKey2 int64  `json:\"myKey\"`",
+		"OddKey string \"json:\\\"odd`key\\\"\"",
+		"Limits Limits `json:\"limits\"`",
+		"CPU float64 `json:\"cpu\"`",
+		"X日本    int64  `json:\"日本\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestGo_EmptyKeysAreRejected(t *testing.T) {
+	files := map[string]string{"data.json": `{"": 1, "name": "x"}`}
+	tr := newTestTranspiler(t, `data = load("data.json")`, func(tr *Transpiler) { tr.SetResolver(NewMapResolver(files)) })
+	_, err := tr.TranspileToGo()
+	if err == nil || !strings.Contains(err.Error(), "Data has an empty key") {
+		t.Errorf("Expected an empty key error, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package transpiler
+
+import (
+	"context"
+	"sort"
+)
+
+// enumMaxValues is the largest set of distinct strings inferred as an enum.
+// A string position only becomes an enum when its values repeat, so a lone
+// "name" isn't pinned to the one value it happens to have.
+const enumMaxValues = 8
+
+// shape accumulates what inference has seen at one position of the document.
+// Array elements are merged into a single shape, so the schema of an array's
+// items covers every element.
+type shape struct {
+	types map[string]bool
+
+	// object positions: how many objects were seen and how many had each key
+	objects  int
+	props    map[string]*shape
+	keyCount map[string]int
+
+	// array positions: the merged shape of every element, nil until one is seen
+	items *shape
+
+	// string positions: the distinct values, nil once there are too many
+	strings     map[string]bool
+	stringCount int
+}
+
+func newShape() *shape {
+	return &shape{types: make(map[string]bool), strings: make(map[string]bool)}
+}
+
+// inferrer walks evaluated values, checking ctx as it goes through large arrays
+type inferrer struct {
+	ctx context.Context
+	n   int
+}
+
+// add merges val into s
+func (in *inferrer) add(s *shape, val interface{}) error {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		s.types["object"] = true
+		if s.props == nil {
+			s.props = make(map[string]*shape)
+			s.keyCount = make(map[string]int)
+		}
+		s.objects++
+		for k, pv := range v {
+			ps, ok := s.props[k]
+			if !ok {
+				ps = newShape()
+				s.props[k] = ps
+			}
+			s.keyCount[k]++
+			if err := in.add(ps, pv); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		s.types["array"] = true
+		if s.items == nil && len(v) > 0 {
+			s.items = newShape()
+		}
+		for _, el := range v {
+			if err := in.tick(); err != nil {
+				return err
+			}
+			if err := in.add(s.items, el); err != nil {
+				return err
+			}
+		}
+	case RangeResult:
+		// Every value of a range is an integer; no need to walk them
+		s.types["array"] = true
+		if v.Len() > 0 {
+			if s.items == nil {
+				s.items = newShape()
+			}
+			s.items.types["integer"] = true
+		}
+	case string:
+		s.types["string"] = true
+		s.stringCount++
+		if s.strings != nil {
+			s.strings[v] = true
+			if len(s.strings) > enumMaxValues {
+				s.strings = nil
+			}
+		}
+	case int64:
+		s.types["integer"] = true
+	case float64, Decimal:
+		s.types["number"] = true
+	case bool:
+		s.types["boolean"] = true
+	case nil:
+		s.types["null"] = true
+	}
+	return nil
+}
+
+// tick checks the context every cancelCheckEvery array elements
+func (in *inferrer) tick() error {
+	in.n++
+	if in.n%cancelCheckEvery == 0 {
+		return in.ctx.Err()
+	}
+	return nil
+}
+
+// typeList returns the JSON types seen at s, sorted. An integer position that
+// also held fractions is a number.
+func (s *shape) typeList() []string {
+	var types []string
+	for typ := range s.types {
+		if typ == "integer" && s.types["number"] {
+			continue
+		}
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// enumValues returns the sorted distinct values of a string-only position
+// whose values repeat, or nil when the position isn't enum-like
+func (s *shape) enumValues() []string {
+	if len(s.typeList()) != 1 || s.strings == nil || len(s.strings) >= s.stringCount {
+		return nil
+	}
+	values := make([]string, 0, len(s.strings))
+	for v := range s.strings {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// schema renders s. path is the dotted output path, used to attach doc
+// comments as descriptions.
+func (s *shape) schema(path string, docs map[string]string) *Schema {
+	out := &Schema{Description: docs[path], Type: s.typeList()}
+
+	if s.types["object"] {
+		out.Properties = make(map[string]*Schema, len(s.props))
+		for k, ps := range s.props {
+			out.Properties[k] = ps.schema(joinPath(path, k), docs)
+			// Required means present in every object seen here
+			if s.keyCount[k] == s.objects {
+				out.Required = append(out.Required, k)
+			}
+		}
+		sort.Strings(out.Required)
+	}
+	if s.items != nil {
+		// Array elements have no path of their own, as in the TypeScript output
+		out.Items = s.items.schema("", nil)
+	}
+	for _, v := range s.enumValues() {
+		out.Enum = append(out.Enum, v)
+	}
+	return out
+}
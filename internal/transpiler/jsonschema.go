@@ -0,0 +1,169 @@
+package transpiler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// schemaDialect is the JSON Schema version written by TranspileToJSONSchema
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document. It covers the keywords JSSON infers and
+// the subset SetSchema validates; decoding fails on any other keyword that
+// constrains values, so a schema is never half checked.
+type Schema struct {
+	Dialect     string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        SchemaTypes        `json:"type,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Const       json.RawMessage    `json:"const,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+
+	// Filled in by ParseSchema
+	ref     *Schema
+	pattern *regexp.Regexp
+	constV  interface{}
+}
+
+// UnmarshalJSON also accepts the boolean schemas true (anything) and false (nothing)
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Not: &Schema{}}
+		return nil
+	}
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	var unsupported []string
+	for _, k := range slices.Sorted(maps.Keys(keywords)) {
+		if !schemaKeywords[k] && !schemaAnnotations[k] {
+			unsupported = append(unsupported, strconv.Quote(k))
+		}
+	}
+	switch len(unsupported) {
+	case 0:
+	case 1:
+		return fmt.Errorf("unsupported keyword %s", unsupported[0])
+	default:
+		return fmt.Errorf("unsupported keywords %s", strings.Join(unsupported, ", "))
+	}
+	// plain has Schema's fields without this method, so decoding doesn't recurse
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// schemaKeywords are the keywords Schema decodes
+var schemaKeywords = map[string]bool{
+	"$schema": true, "$ref": true, "$defs": true, "description": true, "type": true,
+	"enum": true, "const": true, "properties": true, "required": true,
+	"additionalProperties": true, "items": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true,
+}
+
+// schemaAnnotations describe values without constraining them, so they are
+// accepted and ignored
+var schemaAnnotations = map[string]bool{
+	"$id": true, "$comment": true, "title": true, "default": true, "examples": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// SchemaTypes is the "type" keyword: one type name, or a list of them
+type SchemaTypes []string
+
+// MarshalJSON writes a single type as a plain string
+func (st SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(st) == 1 {
+		return json.Marshal(st[0])
+	}
+	return json.Marshal([]string(st))
+}
+
+// UnmarshalJSON accepts both the string and the list form
+func (st *SchemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*st = SchemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*st = many
+	return nil
+}
+
+// TranspileToJSONSchema infers a JSON Schema (draft 2020-12) describing the
+// evaluated document: object shapes with their required keys, one item schema
+// per array covering every element, and enums for small repeated string sets.
+// Doc comments become descriptions.
+func (t *Transpiler) TranspileToJSONSchema() ([]byte, error) {
+	return t.TranspileToJSONSchemaContext(context.Background())
+}
+
+// TranspileToJSONSchemaContext is TranspileToJSONSchema that stops with ctx's
+// error once ctx is done
+func (t *Transpiler) TranspileToJSONSchemaContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := t.inferSchema(ctx, root)
+	if err != nil {
+		return t.limitOutput(ctx, nil, err)
+	}
+	out, err := json.MarshalIndent(schema, "", "  ")
+	return t.limitOutput(ctx, out, err)
+}
+
+// inferSchema infers the schema of an evaluated document
+func (t *Transpiler) inferSchema(ctx context.Context, root map[string]interface{}) (*Schema, error) {
+	in := &inferrer{ctx: ctx}
+	s := newShape()
+	if err := in.add(s, root); err != nil {
+		return nil, err
+	}
+	schema := s.schema("", t.collectDocs())
+	schema.Dialect = schemaDialect
+	return schema, nil
+}
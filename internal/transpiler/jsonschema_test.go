@@ -0,0 +1,119 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func inferSchemaFor(t *testing.T, input string) *Schema {
+	t.Helper()
+	out, err := newTestTranspiler(t, input).TranspileToJSONSchema()
+	if err != nil {
+		t.Fatalf("TranspileToJSONSchema error: %v", err)
+	}
+	var s Schema
+	if err := json.Unmarshal(out, &s); err != nil {
+		t.Fatalf("Invalid schema output: %v\n%s", err, out)
+	}
+	return &s
+}
+
+func TestJSONSchema_Document(t *testing.T) {
+	s := inferSchemaFor(t, "name = \"api\"\nport = 8080\nratio = 0.5\ndebug = false\nnothing = null")
+
+	if s.Dialect != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("Expected the 2020-12 dialect, got %q", s.Dialect)
+	}
+	want := map[string]string{"name": "string", "port": "integer", "ratio": "number", "debug": "boolean", "nothing": "null"}
+	for key, typ := range want {
+		prop := s.Properties[key]
+		if prop == nil || !reflect.DeepEqual(prop.Type, SchemaTypes{typ}) {
+			t.Errorf("%s: expected type %s, got %+v", key, typ, prop)
+		}
+		if prop != nil && prop.Enum != nil {
+			t.Errorf("%s: a single value shouldn't become an enum, got %v", key, prop.Enum)
+		}
+	}
+	if !reflect.DeepEqual(s.Required, []string{"debug", "name", "nothing", "port", "ratio"}) {
+		t.Errorf("Expected every top-level key to be required, got %v", s.Required)
+	}
+}
+
+func TestJSONSchema_ArrayItemsUnified(t *testing.T) {
+	input := `users [
+  template { id, role, score }
+  1, "admin", 1
+  2, "user", 2.5
+  3, "user", 3
+]
+mixed = [ 1, "a", null ]
+ports = 8080..8082
+empty = []
+partial = [ { a = 1, b = 2 }, { a = 3 } ]`
+	s := inferSchemaFor(t, input)
+
+	items := s.Properties["users"].Items
+	if items == nil || !reflect.DeepEqual(items.Required, []string{"id", "role", "score"}) {
+		t.Fatalf("Expected template rows to share one object schema, got %+v", items)
+	}
+	if got := items.Properties["score"].Type; !reflect.DeepEqual(got, SchemaTypes{"number"}) {
+		t.Errorf("Integers mixed with fractions should widen to number, got %v", got)
+	}
+	if got := items.Properties["role"].Enum; !reflect.DeepEqual(got, []interface{}{"admin", "user"}) {
+		t.Errorf("Expected a role enum, got %v", got)
+	}
+
+	if got := s.Properties["mixed"].Items.Type; !reflect.DeepEqual(got, SchemaTypes{"integer", "null", "string"}) {
+		t.Errorf("Expected a union of element types, got %v", got)
+	}
+	if got := s.Properties["ports"].Items.Type; !reflect.DeepEqual(got, SchemaTypes{"integer"}) {
+		t.Errorf("Expected range items to be integers, got %v", got)
+	}
+	if s.Properties["empty"].Items != nil {
+		t.Errorf("An empty array should have no item schema, got %+v", s.Properties["empty"].Items)
+	}
+	if got := s.Properties["partial"].Items.Required; !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Only keys present in every element should be required, got %v", got)
+	}
+}
+
+func TestJSONSchema_DocCommentsBecomeDescriptions(t *testing.T) {
+	s := inferSchemaFor(t, "/// Network settings\nserver {\n  /// Port the HTTP listener binds to\n  port = 8080\n}")
+
+	server := s.Properties["server"]
+	if server.Description != "Network settings" {
+		t.Errorf("Expected the object's doc as description, got %q", server.Description)
+	}
+	if got := server.Properties["port"].Description; got != "Port the HTTP listener binds to" {
+		t.Errorf("Expected the property's doc as description, got %q", got)
+	}
+}
+
+func TestJSONSchema_EnumNeedsRepetition(t *testing.T) {
+	// Distinct values that never repeat are free text, not an enum
+	s := inferSchemaFor(t, `names = [ "ada", "bob", "cy" ]`+"\n"+`levels = [ "a", "b", "c", "d", "e", "f", "g", "h", "i", "a" ]`)
+	if got := s.Properties["names"].Items.Enum; got != nil {
+		t.Errorf("Expected no enum for unique names, got %v", got)
+	}
+	if got := s.Properties["levels"].Items.Enum; got != nil {
+		t.Errorf("Expected no enum past %d distinct values, got %v", enumMaxValues, got)
+	}
+}
+
+func TestSchemaTypes_JSONForms(t *testing.T) {
+	one, _ := json.Marshal(SchemaTypes{"string"})
+	many, _ := json.Marshal(SchemaTypes{"integer", "null"})
+	if string(one) != `"string"` || string(many) != `["integer","null"]` {
+		t.Errorf("Unexpected type encoding: %s, %s", one, many)
+	}
+
+	var st SchemaTypes
+	if err := json.Unmarshal([]byte(`"object"`), &st); err != nil || !reflect.DeepEqual(st, SchemaTypes{"object"}) {
+		t.Errorf("Expected the string form to parse, got %v (%v)", st, err)
+	}
+	if err := json.Unmarshal([]byte(`["string","null"]`), &st); err != nil || !strings.Contains(strings.Join(st, ","), "null") {
+		t.Errorf("Expected the list form to parse, got %v (%v)", st, err)
+	}
+}
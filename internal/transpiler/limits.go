@@ -0,0 +1,160 @@
+package transpiler
+
+import (
+	"context"
+	"errors"
+	"jsson/internal/ast"
+	ie "jsson/internal/errors"
+	"sync/atomic"
+	"time"
+)
+
+// Limits bounds the work a single transpile may do, so untrusted input can't
+// exhaust memory or CPU. A zero field means no limit.
+type Limits struct {
+	// MaxElements caps the values generated by ranges, map and array templates
+	MaxElements int64
+	// MaxOutputBytes caps the size of the encoded output and of any single string
+	MaxOutputBytes int64
+	// MaxDepth caps how deeply expressions may nest during evaluation
+	MaxDepth int
+	// Timeout caps the wall-clock time of one evaluation
+	Timeout time.Duration
+}
+
+// cancelCheckEvery is how many generated elements pass between context checks;
+// asking the context on every step would dominate tight range loops
+const cancelCheckEvery = 1024
+
+// budget tracks what a run has used so far. It is shared with the child
+// transpilers of included files and with parallel map workers, so the whole
+// run counts against one set of limits.
+type budget struct {
+	limits   Limits
+	elements atomic.Int64
+	// output counts bytes written by lazy ranges as they're encoded
+	output atomic.Int64
+}
+
+// errOutputTooLarge stops an encoder once a range has pushed the output past
+// MaxOutputBytes; limitOutput reports it like any other oversized output
+var errOutputTooLarge = errors.New("output too large")
+
+// SetLimits configures resource limits for untrusted input (zero value = unlimited)
+func (t *Transpiler) SetLimits(l Limits) {
+	if l == (Limits{}) {
+		t.budget = nil
+		return
+	}
+	t.budget = &budget{limits: l}
+}
+
+// begin starts a top-level run: it resets the budget, applies the timeout to
+// ctx and records ctx so lazy ranges can stop while they're being encoded.
+// Call the returned func when the run, encoding included, ends.
+func (t *Transpiler) begin(ctx context.Context) (context.Context, context.CancelFunc) {
+	stop := context.CancelFunc(func() {})
+	if b := t.budget; b != nil {
+		b.elements.Store(0)
+		b.output.Store(0)
+		t.depth = 0
+		if b.limits.Timeout > 0 {
+			ctx, stop = context.WithTimeout(ctx, b.limits.Timeout)
+		}
+	}
+	t.runCtx = ctx
+	return ctx, stop
+}
+
+// checkContext reports ctx's error, if any, at node's position
+func (t *Transpiler) checkContext(ctx context.Context, node ast.Node) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	msg := ie.EvaluationCancelled()
+	if errors.Is(err, context.DeadlineExceeded) && t.budget != nil && t.budget.limits.Timeout > 0 {
+		msg = ie.EvaluationTimedOut(t.budget.limits.Timeout)
+	}
+	return t.errfNodeWrap(node, msg, err)
+}
+
+// enterExpression counts one level of evaluation depth; pair it with leaveExpression
+func (t *Transpiler) enterExpression(node ast.Node) error {
+	t.depth++
+	if max := t.budget.limits.MaxDepth; max > 0 && t.depth > max {
+		return t.errfNodeMsg(node, ie.NestingTooDeep(max))
+	}
+	return nil
+}
+
+func (t *Transpiler) leaveExpression() {
+	t.depth--
+}
+
+// addElement is called for each value a range, map or template generates, n
+// being the number generated so far by that loop. It checks ctx every
+// cancelCheckEvery values and counts the value against MaxElements.
+func (t *Transpiler) addElement(ctx context.Context, node ast.Node, n int) error {
+	if n%cancelCheckEvery == 0 {
+		if err := t.checkContext(ctx, node); err != nil {
+			return err
+		}
+	}
+	return t.countElements(node, 1)
+}
+
+// countElements counts n generated values against MaxElements
+func (t *Transpiler) countElements(node ast.Node, n int) error {
+	if t.budget == nil {
+		return nil
+	}
+	b := t.budget
+	if total := b.elements.Add(int64(n)); b.limits.MaxElements > 0 && total > b.limits.MaxElements {
+		return t.errfNodeMsg(node, ie.TooManyElements(b.limits.MaxElements))
+	}
+	return nil
+}
+
+// checkStringSize stops strings built by concatenation or interpolation from
+// outgrowing the output limit before they're ever encoded
+func (t *Transpiler) checkStringSize(node ast.Node, s string) error {
+	if t.budget == nil || t.budget.limits.MaxOutputBytes <= 0 {
+		return nil
+	}
+	if int64(len(s)) > t.budget.limits.MaxOutputBytes {
+		return t.errfNodeMsg(node, ie.OutputTooLarge(t.budget.limits.MaxOutputBytes))
+	}
+	return nil
+}
+
+// chargeOutput counts n encoded bytes against MaxOutputBytes. Ranges call it
+// while they expand, so a huge one stops early instead of being written out
+// in full and rejected afterwards. A nil budget has no limit.
+func (b *budget) chargeOutput(n int) error {
+	if b == nil || b.limits.MaxOutputBytes <= 0 || n == 0 {
+		return nil
+	}
+	if b.output.Add(int64(n)) > b.limits.MaxOutputBytes {
+		return errOutputTooLarge
+	}
+	return nil
+}
+
+// limitOutput passes encoder output through, rejecting it past MaxOutputBytes.
+// An encoder error caused by ctx ending is reported like any other cancellation.
+func (t *Transpiler) limitOutput(ctx context.Context, out []byte, err error) ([]byte, error) {
+	if err != nil {
+		if ctxErr := t.checkContext(ctx, nil); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if errors.Is(err, errOutputTooLarge) {
+			return nil, t.errMsg(ie.OutputTooLarge(t.budget.limits.MaxOutputBytes))
+		}
+		return nil, err
+	}
+	if t.budget != nil && t.budget.limits.MaxOutputBytes > 0 && int64(len(out)) > t.budget.limits.MaxOutputBytes {
+		return nil, t.errMsg(ie.OutputTooLarge(t.budget.limits.MaxOutputBytes))
+	}
+	return out, nil
+}
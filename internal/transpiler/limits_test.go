@@ -0,0 +1,164 @@
+package transpiler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withLimits is a newTestTranspiler option that sets resource limits
+func withLimits(l Limits) func(*Transpiler) {
+	return func(tr *Transpiler) { tr.SetLimits(l) }
+}
+
+func TestLimits_AbortRunawayInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		limits Limits
+		want   string
+	}{
+		{"huge range", "x = 0..9999999999", Limits{MaxElements: 1000}, "more than 1000 elements"},
+		{"string range", `x = "id0".."id99999999"`, Limits{MaxElements: 1000}, "more than 1000 elements"},
+		{"nested maps", "x = (0..99 map (a) = (0..99 map (b) = b))", Limits{MaxElements: 5000}, "more than 5000 elements"},
+		{"template rows", "users [\n template { id }\n 0..99\n]", Limits{MaxElements: 50}, "more than 50 elements"},
+		{"string doubling", "a := \"xxxxxxxx\"\nb := a + a\nc := b + b\nd := c + c\nok = true", Limits{MaxOutputBytes: 40}, "larger than 40 bytes"},
+		{"output size", "x = 0..99", Limits{MaxOutputBytes: 100}, "larger than 100 bytes"},
+		{"deep nesting", "x = " + strings.Repeat("[", 50) + strings.Repeat("]", 50), Limits{MaxDepth: 20}, "deeper than the limit of 20"},
+		{"timeout", "x = 0..999999999", Limits{Timeout: time.Millisecond}, "took longer than 1ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := newTestTranspiler(t, tt.input, withLimits(tt.limits)).Transpile()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Limit took %s to trip", elapsed)
+			}
+		})
+	}
+}
+
+func TestLimits_AllowInputWithinBudget(t *testing.T) {
+	input := "x = (0..9 map (a) = a * 2)\ny = [ 1, [ 2, [ 3 ] ] ]"
+	limits := Limits{MaxElements: 20, MaxOutputBytes: 1024, MaxDepth: 20, Timeout: time.Second}
+	tr := newTestTranspiler(t, input, withLimits(limits))
+	if _, err := tr.Transpile(); err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	// Counters reset between runs on the same transpiler
+	if _, err := tr.TranspileToYAML(); err != nil {
+		t.Fatalf("Second run should get a fresh budget, got %v", err)
+	}
+}
+
+func TestLimits_OutputCapStopsRangeEncoding(t *testing.T) {
+	// Only the byte cap is set, so nothing stops the ranges before encoding;
+	// writing them out in full would take hours and terabytes
+	input := "x = 0..999999999999\ny = { z = 0..999999999999 }"
+	tr := newTestTranspiler(t, input, withLimits(Limits{MaxOutputBytes: 1 << 20}))
+
+	runs := map[string]func() ([]byte, error){
+		"json":       tr.Transpile,
+		"yaml":       tr.TranspileToYAML,
+		"toml":       tr.TranspileToTOML,
+		"typescript": tr.TranspileToTypeScript,
+	}
+	for name, run := range runs {
+		start := time.Now()
+		_, err := run()
+		if err == nil || !strings.Contains(err.Error(), "larger than 1048576 bytes") {
+			t.Errorf("%s: expected output limit error, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: limit took %s to trip", name, elapsed)
+		}
+	}
+}
+
+func TestLimits_SharedWithIncludes(t *testing.T) {
+	r := NewMapResolver(map[string]string{
+		"big.jsson": "items = 0..99\n",
+	})
+	tr := newTestTranspiler(t, "include \"big.jsson\"\nmore = 0..99", withLimits(Limits{MaxElements: 150}), func(tr *Transpiler) {
+		tr.baseDir = "."
+		tr.SetResolver(r)
+	})
+	if _, err := tr.Transpile(); err == nil || !strings.Contains(err.Error(), "more than 150 elements") {
+		t.Fatalf("Expected included elements to count against the limit, got %v", err)
+	}
+}
+
+func TestContext_CancelStopsEvaluation(t *testing.T) {
+	tr := newTestTranspiler(t, "x = (0..9999999999 map (a) = a)", withSourceFile("main.jsson"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := tr.TranspileContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "main.jsson:1:23") {
+		t.Errorf("Expected the error to carry the map position, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Cancellation took %s", elapsed)
+	}
+}
+
+func TestContext_CancelStopsRangeEncoding(t *testing.T) {
+	// A top-level range is only expanded by the encoder, which must stop too
+	tr := newTestTranspiler(t, "x = 0..9999999999")
+
+	runs := map[string]func(context.Context) ([]byte, error){
+		"json": tr.TranspileContext,
+		"yaml": tr.TranspileToYAMLContext,
+		"toml": tr.TranspileToTOMLContext,
+	}
+	for name, run := range runs {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		start := time.Now()
+		_, err := run(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected context.DeadlineExceeded, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: cancellation took %s", name, elapsed)
+		}
+	}
+}
+
+func TestContext_CancelledBeforeEncoding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewMapResolver(map[string]string{"rows.jsson": "rows = 0..10\n"})
+	tr := newTestTranspiler(t, `include "rows.jsson"`, func(tr *Transpiler) {
+		tr.baseDir = "."
+		tr.SetResolver(r)
+	})
+
+	runs := map[string]func(context.Context) ([]byte, error){
+		"json":       tr.TranspileContext,
+		"yaml":       tr.TranspileToYAMLContext,
+		"toml":       tr.TranspileToTOMLContext,
+		"typescript": tr.TranspileToTypeScriptContext,
+	}
+	for name, run := range runs {
+		// The error from inside the included file still unwraps to ctx's error
+		if _, err := run(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package transpiler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"jsson/internal/ast"
+	ie "jsson/internal/errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// evalCall evaluates a call to one of the builtin functions
+func (t *Transpiler) evalCall(ctx context.Context, e *ast.CallExpression, scope *Scope) (interface{}, error) {
+	args := make([]interface{}, 0, len(e.Arguments))
+	for _, argExpr := range e.Arguments {
+		arg, err := t.evalExpression(ctx, argExpr, scope)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	switch e.Function.Value {
+	case "load":
+		if len(args) != 1 {
+			return nil, t.errfNodeMsg(e, ie.WrongArgumentCount("load", 1, len(args)))
+		}
+		path, ok := args[0].(string)
+		if !ok {
+			return nil, t.errfNodeMsg(e, ie.LoadPathNotString(args[0]))
+		}
+		return t.loadDataFile(e, path)
+	}
+	return nil, t.errfNodeMsg(e, ie.UnknownFunction(e.Function.Value))
+}
+
+// loadDataFile reads a JSON, YAML, TOML or CSV file relative to baseDir and
+// converts it to transpiler values (int64, float64, string, bool, nil, maps, slices)
+func (t *Transpiler) loadDataFile(node ast.Node, path string) (interface{}, error) {
+	abs, err := t.resolver.Resolve(t.baseDir, path)
+	if isSandboxError(err) {
+		return nil, t.errfNodeMsg(node, ie.SandboxViolation(path, err))
+	}
+	if err != nil {
+		return nil, t.errfNodeMsg(node, ie.LoadFailed(path, err))
+	}
+
+	if val, ok := t.loadCache[abs]; ok {
+		return val, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(abs))
+	switch ext {
+	case ".json", ".yaml", ".yml", ".toml", ".csv":
+	default:
+		return nil, t.errfNodeMsg(node, ie.UnsupportedLoadFormat(path))
+	}
+
+	if err := t.countFileRead(node); err != nil {
+		return nil, err
+	}
+
+	data, err := t.resolver.ReadFile(abs)
+	if isSandboxError(err) {
+		return nil, t.errfNodeMsg(node, ie.SandboxViolation(path, err))
+	}
+	if err != nil {
+		return nil, t.errfNodeMsg(node, ie.LoadFailed(path, err))
+	}
+
+	val, err := t.decodeData(ext, data)
+	if err != nil {
+		return nil, t.errfNodeMsg(node, ie.LoadFailed(path, err))
+	}
+
+	t.loadCache[abs] = val
+	return val, nil
+}
+
+// decodeData parses data in the format named by ext
+func (t *Transpiler) decodeData(ext string, data []byte) (interface{}, error) {
+	switch ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var raw interface{}
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return t.normalizeLoaded(raw), nil
+	case ".yaml", ".yml":
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return t.normalizeLoaded(raw), nil
+	case ".toml":
+		var raw map[string]interface{}
+		if _, err := toml.Decode(string(data), &raw); err != nil {
+			return nil, err
+		}
+		return t.normalizeLoaded(raw), nil
+	default:
+		return t.decodeCSV(data)
+	}
+}
+
+// decodeCSV turns a CSV file into an array of objects keyed by the header row.
+// Cells are typed like literals: integers, floats, true/false, and empty cells as null.
+func (t *Transpiler) decodeCSV(data []byte) (interface{}, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]interface{}, 0, len(records))
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	for i, h := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+	}
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, key := range header {
+			row[key] = t.csvCell(record[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvCell infers the type of a single CSV cell
+func (t *Transpiler) csvCell(cell string) interface{} {
+	s := strings.TrimSpace(cell)
+	switch s {
+	case "":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	// Only cells that look like numbers are numbers ("NaN" or "Inf" stay strings)
+	if c := s[0]; (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+		if t.decimalMode {
+			if d, ok := NewDecimal(s); ok {
+				return d
+			}
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return cell
+}
+
+// normalizeLoaded converts decoder output into the value types the transpiler works with
+func (t *Transpiler) normalizeLoaded(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = t.normalizeLoaded(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = t.normalizeLoaded(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = t.normalizeLoaded(item)
+		}
+		return out
+	case []map[string]interface{}:
+		// TOML arrays of tables
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = t.normalizeLoaded(item)
+		}
+		return out
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if t.decimalMode {
+			if d, ok := NewDecimal(val.String()); ok {
+				return d
+			}
+		}
+		f, _ := val.Float64()
+		return f
+	case float64:
+		// YAML and TOML decode floats already; their shortest spelling is
+		// what the file meant
+		if t.decimalMode {
+			if d, ok := decimalFromFloat(val); ok {
+				return d
+			}
+		}
+		return val
+	case int:
+		return int64(val)
+	case uint64:
+		if val > 1<<63-1 {
+			return float64(val)
+		}
+		return int64(val)
+	case time.Time:
+		return formatLoadedTime(val)
+	}
+	return v
+}
+
+// formatLoadedTime renders dates as strings; TOML local dates and times keep
+// their own layout (the decoder marks them with named zones)
+func formatLoadedTime(tm time.Time) string {
+	switch tm.Location().String() {
+	case "date-local":
+		return tm.Format("2006-01-02")
+	case "time-local":
+		return tm.Format("15:04:05.999999999")
+	case "datetime-local":
+		return tm.Format("2006-01-02T15:04:05.999999999")
+	}
+	return tm.Format(time.RFC3339Nano)
+}
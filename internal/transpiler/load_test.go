@@ -0,0 +1,160 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func transpileWithFiles(t *testing.T, files map[string]string, input string) (map[string]interface{}, error) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	output, err := newTestTranspiler(t, input, func(tr *Transpiler) { tr.baseDir = dir }).Transpile()
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(output, &root); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	return root, nil
+}
+
+func TestLoad_DataFormats(t *testing.T) {
+	files := map[string]string{
+		"defaults.json": `{"port": 8080, "ratio": 0.25, "tags": ["a", "b"]}`,
+		"labels.yaml":   "app: web\nreplicas: 3\n",
+		"app.toml":      "title = \"shop\"\nreleased = 2024-01-02\n\n[[servers]]\nname = \"alpha\"\n",
+	}
+	input := `
+defaults := load("defaults.json")
+port = defaults.port + 1
+ratio = defaults.ratio
+tags = defaults.tags
+labels = load("labels.yaml")
+app = load("app.toml")
+`
+	root, err := transpileWithFiles(t, files, input)
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+
+	if root["port"] != float64(8081) || root["ratio"] != 0.25 {
+		t.Errorf("Unexpected JSON values: port=%v ratio=%v", root["port"], root["ratio"])
+	}
+	if tags, ok := root["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("Expected tags array, got %v", root["tags"])
+	}
+	labels := root["labels"].(map[string]interface{})
+	if labels["app"] != "web" || labels["replicas"] != float64(3) {
+		t.Errorf("Unexpected YAML values: %v", labels)
+	}
+	app := root["app"].(map[string]interface{})
+	if app["released"] != "2024-01-02" {
+		t.Errorf("Expected TOML local date as string, got %v", app["released"])
+	}
+	if servers, ok := app["servers"].([]interface{}); !ok || len(servers) != 1 {
+		t.Errorf("Expected TOML array of tables, got %v", app["servers"])
+	}
+}
+
+func TestLoad_CSVTypesAndTemplateRows(t *testing.T) {
+	files := map[string]string{
+		"users.csv": "id,name,email,active,score\n1,Ana,ana@example.com,true,9.5\n2,Bo,,false,7\n",
+	}
+	input := `
+raw = load("users.csv")
+users [
+  template { id, email }
+  load("users.csv")
+]
+labels [
+  template { id, name }
+  map (u) = { id = u.id, label = "user-" + u.name }
+  load("users.csv")
+]
+`
+	root, err := transpileWithFiles(t, files, input)
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+
+	raw := root["raw"].([]interface{})
+	first := raw[0].(map[string]interface{})
+	if first["id"] != float64(1) || first["active"] != true || first["score"] != 9.5 || first["name"] != "Ana" {
+		t.Errorf("Unexpected CSV typing: %v", first)
+	}
+	if second := raw[1].(map[string]interface{}); second["email"] != nil {
+		t.Errorf("Expected empty cell to be null, got %v", second["email"])
+	}
+
+	users := root["users"].([]interface{})
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 template rows, got %d", len(users))
+	}
+	user := users[0].(map[string]interface{})
+	if len(user) != 2 || user["email"] != "ana@example.com" {
+		t.Errorf("Expected only template keys, got %v", user)
+	}
+
+	labels := root["labels"].([]interface{})
+	if labels[1].(map[string]interface{})["label"] != "user-Bo" {
+		t.Errorf("Expected map clause applied to loaded rows, got %v", labels)
+	}
+}
+
+func TestLoad_ArrayCellsInTemplatesStayValues(t *testing.T) {
+	// Only a load() row expands into records; an array of objects written in a
+	// cell is that cell's value, as it always was
+	input := `
+single [
+  template { tags }
+  [ { a = 1 }, { a = 2 } ]
+]
+pair [
+  template { id, tags }
+  1, [ { a = 1 } ]
+]
+`
+	root := transpileToMap(t, input)
+
+	out, _ := json.Marshal(root)
+	want := `{"pair":[{"id":1,"tags":[{"a":1}]}],"single":[{"tags":[{"a":1},{"a":2}]}]}`
+	if string(out) != want {
+		t.Errorf("Expected %s, got %s", want, out)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`x = load("missing.json")`, "could not load"},
+		{`x = load("notes.txt")`, "can't read"},
+		{`x = load(42)`, "needs a file path string"},
+		{`x = load("a.json", "b.json")`, "takes 1 argument(s)"},
+		{`x = fetch("a.json")`, "unknown function fetch()"},
+		{`x = load("broken.json")`, "could not load"},
+	}
+	files := map[string]string{"broken.json": `{"a": `}
+
+	for _, tt := range tests {
+		_, err := transpileWithFiles(t, files, tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"jsson/internal/lexer"
+	"jsson/internal/parser"
+)
+
+// newTestTranspiler parses input and returns a transpiler for it, with each
+// of opts applied. Tests configure modes and limits through opts.
+func newTestTranspiler(t *testing.T, input string, opts ...func(*Transpiler)) *Transpiler {
+	t.Helper()
+	l := lexer.New(input)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+	tr := New(prog, "", "keep", "")
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
+}
+
+// transpileToMap transpiles input to JSON and decodes the result
+func transpileToMap(t *testing.T, input string, opts ...func(*Transpiler)) map[string]interface{} {
+	t.Helper()
+	output, err := newTestTranspiler(t, input, opts...).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(output, &root); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	return root
+}
+
+func TestNull_Literal(t *testing.T) {
+	root := transpileToMap(t, "data = null\nlist = [ 1, null ]")
+
+	if v, ok := root["data"]; !ok || v != nil {
+		t.Errorf("Expected data to be null, got: %v", root["data"])
+	}
+	list, ok := root["list"].([]interface{})
+	if !ok || len(list) != 2 || list[1] != nil {
+		t.Errorf("Expected [1, null], got: %v", root["list"])
+	}
+}
+
+func TestNull_Coalescing(t *testing.T) {
+	input := `
+fallback := null
+data = fallback ?? "default"
+zero = 0 ?? 5
+empty = "" ?? "unused"
+`
+	root := transpileToMap(t, input)
+
+	if root["data"] != "default" {
+		t.Errorf("Expected data=\"default\", got: %v", root["data"])
+	}
+	// Only null triggers the default, unlike ||
+	if root["zero"] != float64(0) {
+		t.Errorf("Expected zero=0, got: %v", root["zero"])
+	}
+	if root["empty"] != "" {
+		t.Errorf("Expected empty=\"\", got: %v", root["empty"])
+	}
+}
+
+func TestNull_OptionalChaining(t *testing.T) {
+	input := `
+server := { host = "localhost" }
+nothing := null
+host = server?.host
+port = server?.port ?? 8080
+deep = nothing?.a.b.c
+`
+	root := transpileToMap(t, input)
+
+	if root["host"] != "localhost" {
+		t.Errorf("Expected host=localhost, got: %v", root["host"])
+	}
+	if root["port"] != float64(8080) {
+		t.Errorf("Expected port=8080, got: %v", root["port"])
+	}
+	if v, ok := root["deep"]; !ok || v != nil {
+		t.Errorf("Expected deep=null, got: %v", root["deep"])
+	}
+}
+
+func TestNull_OptionalChainOnlySkipsNullReceivers(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing key after a present one", "a := { b = { x = 1 } }\nv = a?.b.c", `"c" not found`},
+		{"null value after a present one", "a := { b = null }\nv = a?.b.c", "not an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestTranspiler(t, tt.input).Transpile()
+			if err == nil {
+				t.Fatal("Expected '?.' to leave the rest of the chain checked")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNull_PlainMemberAccessStillFails(t *testing.T) {
+	input := `
+server := { host = "localhost" }
+port = server.port
+`
+	_, err := newTestTranspiler(t, input).Transpile()
+	if err == nil {
+		t.Fatal("Expected error for missing property without '?.'")
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestNull_TruthinessAndEquality(t *testing.T) {
+	input := `
+nothing := null
+isNull = nothing == null
+notNull = 0 != null
+truthy = nothing ? "yes" : "no"
+text = "value: " + nothing
+`
+	root := transpileToMap(t, input)
+
+	if root["isNull"] != true {
+		t.Errorf("Expected null == null, got: %v", root["isNull"])
+	}
+	if root["notNull"] != true {
+		t.Errorf("Expected 0 != null, got: %v", root["notNull"])
+	}
+	if root["truthy"] != "no" {
+		t.Errorf("Expected null to be falsy, got: %v", root["truthy"])
+	}
+	if root["text"] != "value: null" {
+		t.Errorf("Expected \"value: null\", got: %v", root["text"])
+	}
+}
+
+func TestNull_TernaryWithFraction(t *testing.T) {
+	// "?." must not swallow the start of a ternary branch
+	root := transpileToMap(t, "data = true ?.5 : 2")
+	if root["data"] != 0.5 {
+		t.Errorf("Expected 0.5, got: %v", root["data"])
+	}
+}
+
+func TestNull_TOMLOmitsNullKeys(t *testing.T) {
+	input := "name = \"app\"\nowner = null\nserver { host = null\n port = 80 }"
+	output, err := newTestTranspiler(t, input).TranspileToTOML()
+	if err != nil {
+		t.Fatalf("TOML error: %v", err)
+	}
+
+	out := string(output)
+	if strings.Contains(out, "owner") || strings.Contains(out, "host") {
+		t.Errorf("Expected null keys to be omitted, got:\n%s", out)
+	}
+	if !strings.Contains(out, "port = 80") {
+		t.Errorf("Expected non-null keys to remain, got:\n%s", out)
+	}
+}
+
+func TestNull_TOMLRejectsNullInArray(t *testing.T) {
+	input := "name = \"app\"\nlist = [ 1, null ]"
+	tr := newTestTranspiler(t, input, withSourceFile("main.jsson"))
+	_, err := tr.TranspileToTOML()
+	if err == nil {
+		t.Fatal("Expected error for null inside TOML array")
+	}
+	if !strings.Contains(err.Error(), "list[1]") {
+		t.Errorf("Expected error to name list[1], got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "main.jsson:2:") {
+		t.Errorf("Expected error at the list assignment, got: %v", err)
+	}
+}
+
+func TestNull_YAMLAndTypeScript(t *testing.T) {
+	input := "owner = null"
+	yamlOut, err := newTestTranspiler(t, input).TranspileToYAML()
+	if err != nil {
+		t.Fatalf("YAML error: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "owner: null") {
+		t.Errorf("Expected owner: null in YAML, got: %s", yamlOut)
+	}
+
+	tsOut, err := newTestTranspiler(t, input).TranspileToTypeScript()
+	if err != nil {
+		t.Fatalf("TypeScript error: %v", err)
+	}
+	if !strings.Contains(string(tsOut), "export const owner = null") {
+		t.Errorf("Expected null const in TypeScript, got: %s", tsOut)
+	}
+}
@@ -0,0 +1,165 @@
+package transpiler
+
+import (
+	"context"
+	"jsson/internal/ast"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelMinItems is the smallest map worth spreading over workers; below it
+// the goroutine handoff costs more than the body
+const parallelMinItems = 256
+
+// SetParallelism evaluates the iterations of large maps (map expressions and
+// template map clauses) on up to workers goroutines. Output order is kept.
+// 0 or 1 keeps evaluation sequential, which is the default.
+func (t *Transpiler) SetParallelism(workers int) {
+	t.parallelism = workers
+}
+
+// mapItems evaluates body once per item with name bound to the item. Each
+// iteration binds one variable on top of the shared parent scope.
+func (t *Transpiler) mapItems(ctx context.Context, node ast.Node, items sequence, name string, body ast.Expression, scope *Scope) ([]interface{}, error) {
+	if t.parallelism > 1 && items.Len() >= parallelMinItems && !usesFiles(body) {
+		return t.mapItemsParallel(ctx, node, items, name, body, scope)
+	}
+
+	// Start from an empty (not nil) slice so mapping nothing yields [] rather than
+	// null; the capacity is capped so a huge lazy range grows the slice gradually
+	result := make([]interface{}, 0, min(items.Len(), 1<<16))
+	for i := 0; i < items.Len(); i++ {
+		if i%cancelCheckEvery == 0 {
+			if err := t.checkContext(ctx, node); err != nil {
+				return nil, err
+			}
+		}
+		mappedVal, err := t.evalExpression(ctx, body, scope.Bind(name, items.Index(i)))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, mappedVal)
+	}
+	return result, nil
+}
+
+// mapItemsParallel is mapItems on a worker pool. Workers claim items by index
+// and write results into their slot, so the output keeps the input order.
+// The first error cancels the remaining work.
+func (t *Transpiler) mapItemsParallel(ctx context.Context, node ast.Node, items sequence, name string, body ast.Expression, scope *Scope) ([]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := min(t.parallelism, items.Len())
+	result := make([]interface{}, items.Len())
+	var (
+		next     atomic.Int64
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for w := 0; w < workers; w++ {
+		// Each worker evaluates on its own copy of the transpiler so the depth
+		// counter is per goroutine; nested maps inside a worker stay sequential
+		worker := *t
+		worker.parallelism = 0
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= items.Len() {
+					return
+				}
+				if i%cancelCheckEvery == 0 || ctx.Err() != nil {
+					if err := worker.checkContext(ctx, node); err != nil {
+						fail(err)
+						return
+					}
+				}
+				val, err := worker.evalExpression(ctx, body, scope.Bind(name, items.Index(i)))
+				if err != nil {
+					fail(err)
+					return
+				}
+				result[i] = val
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
+
+// usesFiles reports whether evaluating expr may include or load files. The
+// include and load caches aren't safe for concurrent use, so such map bodies
+// run sequentially.
+func usesFiles(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.CallExpression:
+		return true
+	case *ast.ObjectLiteral:
+		if len(e.Includes) > 0 {
+			return true
+		}
+		for _, decl := range e.Declarations {
+			if usesFiles(decl.Value) {
+				return true
+			}
+		}
+		for _, key := range e.Keys {
+			if v := e.Properties[key]; v != nil && usesFiles(v) {
+				return true
+			}
+		}
+		for _, a := range e.Asserts {
+			if usesFiles(a.Condition) || (a.Message != nil && usesFiles(a.Message)) {
+				return true
+			}
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range e.Elements {
+			if usesFiles(el) {
+				return true
+			}
+		}
+	case *ast.ArrayTemplate:
+		for _, row := range e.Rows {
+			for _, cell := range row {
+				if usesFiles(cell) {
+					return true
+				}
+			}
+		}
+		return e.Map != nil && usesFiles(e.Map.Body)
+	case *ast.MapExpression:
+		return usesFiles(e.Left) || usesFiles(e.Body)
+	case *ast.RangeExpression:
+		return usesFiles(e.Start) || usesFiles(e.End) || (e.Step != nil && usesFiles(e.Step))
+	case *ast.BinaryExpression:
+		return usesFiles(e.Left) || usesFiles(e.Right)
+	case *ast.PrefixExpression:
+		return usesFiles(e.Right)
+	case *ast.MemberExpression:
+		return usesFiles(e.Left)
+	case *ast.ConditionalExpression:
+		return usesFiles(e.Condition) || usesFiles(e.Consequence) || usesFiles(e.Alternative)
+	case *ast.InterpolatedString:
+		for _, part := range e.Parts {
+			if p, ok := part.(ast.Expression); ok && usesFiles(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package transpiler
+
+import (
+	"context"
+	"jsson/internal/lexer"
+	"jsson/internal/parser"
+	"runtime"
+	"testing"
+)
+
+// benchmarkEval measures evaluation alone (no encoding) with the given worker count
+func benchmarkEval(b *testing.B, input string, workers int) {
+	l := lexer.New(input)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		b.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr := New(prog, "", "keep", "")
+		tr.SetParallelism(workers)
+		if _, err := tr.evalProgram(context.Background()); err != nil {
+			b.Fatalf("Eval error: %v", err)
+		}
+	}
+}
+
+const benchMapRows = "data = (0..99999 map (x) = { id = x, name = `user-${x}`, score = x * 3 % 100, active = x % 2 == 0 })"
+
+// Benchmark a 100k-row map, sequential
+func BenchmarkMapRows_Sequential(b *testing.B) {
+	benchmarkEval(b, benchMapRows, 0)
+}
+
+// Benchmark the same map on one worker per CPU
+func BenchmarkMapRows_Parallel(b *testing.B) {
+	benchmarkEval(b, benchMapRows, runtime.GOMAXPROCS(0))
+}
+
+const benchTemplateMap = `users [
+  template { id, zone }
+  map (u) = { id = u.id, zone = u.zone, host = ` + "`${u.zone}-${u.id}.internal`" + ` }
+
+  0..99999, "eu"
+]`
+
+// Benchmark a template map clause over 100k rows, sequential
+func BenchmarkTemplateMap_Sequential(b *testing.B) {
+	benchmarkEval(b, benchTemplateMap, 0)
+}
+
+// Benchmark the same template map clause on one worker per CPU
+func BenchmarkTemplateMap_Parallel(b *testing.B) {
+	benchmarkEval(b, benchTemplateMap, runtime.GOMAXPROCS(0))
+}
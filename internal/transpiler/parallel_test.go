@@ -0,0 +1,68 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+
+	"jsson/internal/ast"
+)
+
+// withParallelism is a newTestTranspiler option that sets the map worker count
+func withParallelism(workers int) func(*Transpiler) {
+	return func(tr *Transpiler) { tr.SetParallelism(workers) }
+}
+
+func TestParallel_MatchesSequentialOutput(t *testing.T) {
+	inputs := []string{
+		"offset := 7\ndata = (0..4999 map (x) = { id = x + offset, name = `user-${x}`, even = x % 2 == 0 })",
+		"matrix = (0..299 map (y) = (0..299 map (x) = x * y))",
+		"users [\n  template { id, zone }\n  map (u) = { id = u.id, host = `${u.zone}-${u.id}` }\n\n  0..999, \"eu\"\n]",
+		"tiny = (0..9 map (x) = x * 2)",
+	}
+	for _, input := range inputs {
+		want, err := newTestTranspiler(t, input, withParallelism(0)).Transpile()
+		if err != nil {
+			t.Fatalf("Sequential error: %v", err)
+		}
+		got, err := newTestTranspiler(t, input, withParallelism(8)).Transpile()
+		if err != nil {
+			t.Fatalf("Parallel error: %v", err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("Parallel output differs from sequential for %q", input)
+		}
+	}
+}
+
+func TestParallel_ReportsErrors(t *testing.T) {
+	_, err := newTestTranspiler(t, "data = (0..999 map (x) = x == 500 ? 1 / 0 : x)", withParallelism(4)).Transpile()
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Fatalf("Expected division by zero from a worker, got %v", err)
+	}
+}
+
+func TestParallel_CountsAgainstLimits(t *testing.T) {
+	tr := newTestTranspiler(t, "data = (0..999 map (x) = (0..9 map (y) = y))", withParallelism(4), withLimits(Limits{MaxElements: 5000}))
+	if _, err := tr.Transpile(); err == nil || !strings.Contains(err.Error(), "more than 5000 elements") {
+		t.Fatalf("Expected element limit across workers, got %v", err)
+	}
+}
+
+func TestParallel_FileAccessStaysSequential(t *testing.T) {
+	tr := newTestTranspiler(t, `data = (0..999 map (x) = { n = load("data.json").base + x })`, withParallelism(4), func(tr *Transpiler) {
+		tr.baseDir = "."
+		tr.SetResolver(NewMapResolver(map[string]string{"data.json": `{"base": 10}`}))
+	})
+	mapExpr := tr.program.Statements[0].(*ast.AssignmentStatement).Value.(*ast.MapExpression)
+	if !usesFiles(mapExpr.Body) {
+		t.Fatalf("Expected load() inside the body to count as file access")
+	}
+
+	output, err := tr.Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	if !strings.Contains(string(output), `"n": 1009`) {
+		t.Errorf("Expected the last row to be computed, got %d bytes", len(output))
+	}
+}
@@ -0,0 +1,319 @@
+package transpiler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Python output modes, set with SetPythonMode
+const (
+	// PythonData writes a module that assigns each top-level key as a Python literal
+	PythonData = "data"
+	// PythonPydantic writes Pydantic model classes inferred from the document
+	PythonPydantic = "pydantic"
+)
+
+// pyKeywords can't be used as names
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true, "def": true,
+	"del": true, "elif": true, "else": true, "except": true, "finally": true, "for": true,
+	"from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// pydanticReserved are attributes of a Pydantic v2 BaseModel that a field
+// can't shadow: the v1 methods it still carries as deprecated aliases. Names
+// starting with model_ are kept clear separately.
+var pydanticReserved = map[string]bool{
+	"construct": true, "copy": true, "dict": true, "from_orm": true, "json": true,
+	"parse_file": true, "parse_obj": true, "parse_raw": true, "schema": true,
+	"schema_json": true, "update_forward_refs": true, "validate": true,
+}
+
+// pyEmptyName stands in for a key with nothing to spell a name from. A
+// leading underscore would make the name private, which Pydantic skips.
+const pyEmptyName = "field"
+
+// pyIdentifier matches the keys that already are Python names
+var pyIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pyNames are the names a Pydantic module imports, which models can't take
+var pyNames = []string{"Any", "BaseModel", "Field", "List", "Literal", "Optional", "Union"}
+
+// SetPythonMode chooses what TranspileToPython writes: PythonData (the
+// default) or PythonPydantic
+func (t *Transpiler) SetPythonMode(mode string) {
+	t.pyMode = mode
+}
+
+// TranspileToPython writes the evaluated document as a Python module: the
+// values themselves, or Pydantic models describing them
+func (t *Transpiler) TranspileToPython() ([]byte, error) {
+	return t.TranspileToPythonContext(context.Background())
+}
+
+// TranspileToPythonContext is TranspileToPython that stops with ctx's error
+// once ctx is done
+func (t *Transpiler) TranspileToPythonContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by jsson. Do not edit.\n")
+	if t.pyMode == PythonPydantic {
+		m, err := t.inferTypeModel(ctx, root, pyNames...)
+		if err != nil {
+			return t.limitOutput(ctx, nil, err)
+		}
+		writePydanticModels(&buf, m)
+		return t.limitOutput(ctx, buf.Bytes(), nil)
+	}
+
+	docs := t.collectDocs()
+	taken := make(map[string]bool, len(root))
+	for _, key := range slices.Sorted(maps.Keys(root)) {
+		buf.WriteString("\n")
+		writeLineComments(&buf, docs[key], "#")
+		buf.WriteString(uniqueIdent(taken, pyName(key)) + " = ")
+		writePythonValue(&buf, root[key], 0, key, docs)
+		buf.WriteString("\n")
+	}
+	return t.limitOutput(ctx, buf.Bytes(), nil)
+}
+
+// pyName turns a key into a Python name. Keys that already are names keep
+// their spelling.
+func pyName(key string) string {
+	if !pyIdentifier.MatchString(key) {
+		key = snakeIdent(key)
+	}
+	switch {
+	case key == "":
+		key = pyEmptyName
+	case pyKeywords[key]:
+		key += "_"
+	}
+	return key
+}
+
+// writePythonValue writes value as a Python literal. path is the dotted
+// output path of value, used to look up doc comments for dict keys.
+func writePythonValue(buf *bytes.Buffer, value interface{}, indent int, path string, docs map[string]string) {
+	indentStr := strings.Repeat("    ", indent)
+
+	switch v := value.(type) {
+	case string:
+		// JSON escapes are all valid in Python strings
+		buf.WriteString(quoteTypeScriptString(v))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		buf.WriteString(pythonFloat(v))
+	case Decimal:
+		s := v.String()
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		buf.WriteString(s)
+	case bool:
+		if v {
+			buf.WriteString("True")
+		} else {
+			buf.WriteString("False")
+		}
+	case nil:
+		buf.WriteString("None")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			writeLineComments(buf, docs[joinPath(path, k)], indentStr+"    #")
+			buf.WriteString(indentStr + "    " + quoteTypeScriptString(k) + ": ")
+			writePythonValue(buf, v[k], indent+1, joinPath(path, k), docs)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(indentStr + "}")
+	case RangeResult:
+		// range() is exclusive, and empty ranges still need a step that isn't 0
+		if v.Len() == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		// One step past the last value may not fit in an int64
+		end := new(big.Int).Add(big.NewInt(v.At(v.Len()-1)), big.NewInt(v.Step))
+		buf.WriteString(fmt.Sprintf("list(range(%d, %s, %d))", v.Start, end, v.Step))
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for _, val := range v {
+			buf.WriteString(indentStr + "    ")
+			writePythonValue(buf, val, indent+1, "", nil)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(indentStr + "]")
+	default:
+		buf.WriteString(fmt.Sprintf("%v", v))
+	}
+}
+
+// pythonFloat writes f so Python reads it back as a float, not an int
+func pythonFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return `float("inf")`
+	case math.IsInf(f, -1):
+		return `float("-inf")`
+	case math.IsNaN(f):
+		return `float("nan")`
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// writePydanticModels writes a model class for every record of m, with the
+// typing imports the annotations use
+func writePydanticModels(buf *bytes.Buffer, m *typeModel) {
+	used := make(map[string]bool)
+	var body bytes.Buffer
+	for _, r := range m.types {
+		body.WriteString(fmt.Sprintf("\n\nclass %s(BaseModel):\n", r.name))
+		fields := r.fields()
+		if len(fields) == 0 {
+			body.WriteString("    pass\n")
+		}
+		names := make(map[string]bool, len(fields))
+		for _, f := range fields {
+			writeLineComments(&body, f.doc, "    #")
+			name := snakeIdent(f.key)
+			switch {
+			case name == "":
+				name = pyEmptyName
+			case pyKeywords[name] || pydanticReserved[name] || strings.HasPrefix(name, "model_"):
+				name += "_"
+			}
+			name = uniqueIdent(names, name)
+
+			typ := m.pythonType(f.shape, used)
+			if f.optional && !strings.HasPrefix(typ, "Optional[") && typ != "None" {
+				used["Optional"] = true
+				typ = "Optional[" + typ + "]"
+			}
+			// Keys that aren't snake_case names are matched through an alias
+			alias := "alias=" + quoteTypeScriptString(f.key)
+			switch {
+			case name != f.key && f.optional:
+				used["Field"] = true
+				typ += " = Field(default=None, " + alias + ")"
+			case name != f.key:
+				used["Field"] = true
+				typ += " = Field(" + alias + ")"
+			case f.optional:
+				typ += " = None"
+			}
+			body.WriteString(fmt.Sprintf("    %s: %s\n", name, typ))
+		}
+	}
+
+	var typing []string
+	for _, name := range []string{"Any", "List", "Literal", "Optional", "Union"} {
+		if used[name] {
+			typing = append(typing, name)
+		}
+	}
+	buf.WriteString("\n")
+	if len(typing) > 0 {
+		buf.WriteString("from typing import " + strings.Join(typing, ", ") + "\n\n")
+	}
+	if used["Field"] {
+		buf.WriteString("from pydantic import BaseModel, Field\n")
+	} else {
+		buf.WriteString("from pydantic import BaseModel\n")
+	}
+	buf.Write(body.Bytes())
+}
+
+// pythonType spells the annotation of a position, noting the typing names it uses
+func (m *typeModel) pythonType(s *shape, used map[string]bool) string {
+	types, nullable := s.valueTypes()
+	var parts []string
+	for _, typ := range types {
+		switch typ {
+		case "object":
+			parts = append(parts, m.names[s])
+		case "array":
+			elem := "Any"
+			if s.items != nil {
+				elem = m.pythonType(s.items, used)
+			} else {
+				used["Any"] = true
+			}
+			used["List"] = true
+			parts = append(parts, "List["+elem+"]")
+		case "string":
+			values := s.enumValues()
+			if values == nil {
+				parts = append(parts, "str")
+				continue
+			}
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = quoteTypeScriptString(v)
+			}
+			used["Literal"] = true
+			parts = append(parts, "Literal["+strings.Join(quoted, ", ")+"]")
+		case "integer":
+			parts = append(parts, "int")
+		case "number":
+			parts = append(parts, "float")
+		case "boolean":
+			parts = append(parts, "bool")
+		}
+	}
+
+	var typ string
+	switch len(parts) {
+	case 0:
+		if nullable {
+			return "None"
+		}
+		used["Any"] = true
+		return "Any"
+	case 1:
+		typ = parts[0]
+	default:
+		used["Union"] = true
+		typ = "Union[" + strings.Join(parts, ", ") + "]"
+	}
+	if nullable {
+		used["Optional"] = true
+		typ = "Optional[" + typ + "]"
+	}
+	return typ
+}
@@ -0,0 +1,96 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPython_DataModule(t *testing.T) {
+	input := `/// Service name
+name = "api"
+debug = false
+ratio = 2.0
+nothing = null
+ids = 1..3
+down = 10..0 step -5
+class = "x"
+server { host = "h", tls = true }
+empty = []`
+	out := generate(t, input, nil, nil, (*Transpiler).TranspileToPython)
+
+	for _, want := range []string{
+		"# Generated by jsson. Do not edit.\n",
+		"\nclass_ = \"x\"\n",
+		"\ndebug = False\n",
+		"\ndown = list(range(10, -5, -5))\n",
+		"\nempty = []\n",
+		"\nids = list(range(1, 4, 1))\n",
+		"\n# Service name\nname = \"api\"\n",
+		"\nnothing = None\n",
+		"\nratio = 2.0\n",
+		"\nserver = {\n    \"host\": \"h\",\n    \"tls\": True,\n}\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestPython_Floats(t *testing.T) {
+	for f, want := range map[float64]string{2: "2.0", 0.5: "0.5", 1e22: "1e+22", -3e-7: "-3e-07", 0: "0.0"} {
+		if got := pythonFloat(f); got != want {
+			t.Errorf("pythonFloat(%v) = %s, want %s", f, got, want)
+		}
+	}
+}
+
+func TestPython_PydanticModels(t *testing.T) {
+	input := `users = [
+  { id = 1, role = "admin", apiUrl = "a", email = "a@x.io" }
+  { id = 2, role = "user", apiUrl = "b" }
+  { id = 3, role = "user", apiUrl = "c", email = null, tags = [ "x", 1 ] }
+]
+/// Service name
+name = "api"
+json = true`
+	out := generate(t, input, nil, func(tr *Transpiler) { tr.SetPythonMode(PythonPydantic) }, (*Transpiler).TranspileToPython)
+
+	want := `# Generated by jsson. Do not edit.
+
+from typing import List, Literal, Optional, Union
+
+from pydantic import BaseModel, Field
+
+
+class User(BaseModel):
+    api_url: str = Field(alias="apiUrl")
+    email: Optional[str] = None
+    id: int
+    role: Literal["admin", "user"]
+    tags: Optional[List[Union[int, str]]] = None
+
+
+class Config(BaseModel):
+    json_: bool = Field(alias="json")
+    # Service name
+    name: str
+    users: List[User]
+`
+	if out != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestPython_EmptyKeysGetPublicNames(t *testing.T) {
+	files := map[string]string{"data.json": `{"": 1, "model_config": true}`}
+	out := generate(t, `data = load("data.json")`, files, func(tr *Transpiler) { tr.SetPythonMode(PythonPydantic) }, (*Transpiler).TranspileToPython)
+
+	for _, want := range []string{
+		`    field: int = Field(alias="")`,
+		`    model_config_: bool = Field(alias="model_config")`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
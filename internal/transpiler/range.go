@@ -0,0 +1,158 @@
+package transpiler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RangeResult is the value of an integer range expression. It is lazy: only
+// the bounds are stored, and consumers (map, template zipping, array literals)
+// read values by index. The encoders write the numbers straight out, so a
+// range reaches the output without ever becoming a []interface{}.
+type RangeResult struct {
+	Start int64
+	Step  int64
+	n     int
+	// ctx is the run that produced the range; encoding stops once it's done
+	ctx context.Context
+	// budget is the run's limits; encoding charges its bytes to MaxOutputBytes
+	budget *budget
+}
+
+// newRange describes start..end in steps of step (step != 0). ok is false if
+// the range has more values than an int can count.
+func newRange(start, end, step int64) (RangeResult, bool) {
+	r := RangeResult{Start: start, Step: step}
+	// Work in uint64 so bounds near the int64 limits can't overflow
+	var span, stride uint64
+	switch {
+	case step > 0 && start <= end:
+		span, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start >= end:
+		span, stride = uint64(start)-uint64(end), -uint64(step)
+	default:
+		return r, true // the step points away from end: empty
+	}
+	if span/stride >= math.MaxInt {
+		return r, false
+	}
+	r.n = int(span/stride) + 1
+	return r, true
+}
+
+// Len returns the number of values in the range
+func (r RangeResult) Len() int { return r.n }
+
+// At returns the i-th value. The product may wrap, but the sum lands back on
+// the true value, which is always within the bounds.
+func (r RangeResult) At(i int) int64 { return r.Start + int64(i)*r.Step }
+
+// Index returns the i-th value boxed, satisfying sequence
+func (r RangeResult) Index(i int) interface{} { return r.At(i) }
+
+// Values materialises the range as a slice
+func (r RangeResult) Values() []interface{} {
+	out := make([]interface{}, r.n)
+	for i := range out {
+		out[i] = r.At(i)
+	}
+	return out
+}
+
+// String prints the range like an array, e.g. for string interpolation
+func (r RangeResult) String() string {
+	return fmt.Sprint(r.Values())
+}
+
+// MarshalJSON writes the range as a JSON array of integers
+func (r RangeResult) MarshalJSON() ([]byte, error) {
+	return r.appendList(",")
+}
+
+// MarshalTOML writes the range as an inline TOML array
+func (r RangeResult) MarshalTOML() ([]byte, error) {
+	return r.appendList(", ")
+}
+
+func (r RangeResult) appendList(sep string) ([]byte, error) {
+	// Size the buffer for small ranges; huge ones grow as they go, so a
+	// cancelled run never allocates the whole output up front
+	buf := make([]byte, 0, 2+min(r.n, 1<<16)*(len(sep)+3))
+	buf = append(buf, '[')
+	err := r.expand(func(i int, v int64) int {
+		start := len(buf)
+		if i > 0 {
+			buf = append(buf, sep...)
+		}
+		buf = strconv.AppendInt(buf, v, 10)
+		return len(buf) - start
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, ']'), nil
+}
+
+// MarshalYAML writes the range as a YAML sequence of integers
+func (r RangeResult) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: make([]*yaml.Node, 0, min(r.n, 1<<16))}
+	err := r.expand(func(_ int, v int64) int {
+		value := strconv.FormatInt(v, 10)
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value})
+		// At least "- " and a newline around the number
+		return len(value) + 3
+	})
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// expand passes each value to emit, which writes it and returns the bytes it
+// wrote. Every cancelCheckEvery values it stops if the run is done or the
+// bytes written so far pass MaxOutputBytes.
+func (r RangeResult) expand(emit func(i int, v int64) int) error {
+	pending := 0
+	for i := 0; i < r.n; i++ {
+		if i%cancelCheckEvery == 0 {
+			if r.ctx != nil {
+				if err := r.ctx.Err(); err != nil {
+					return err
+				}
+			}
+			if err := r.budget.chargeOutput(pending); err != nil {
+				return err
+			}
+			pending = 0
+		}
+		pending += emit(i, r.At(i))
+	}
+	return r.budget.chargeOutput(pending)
+}
+
+// sequence is an array value read by index: an evaluated array or a lazy range
+type sequence interface {
+	Len() int
+	Index(i int) interface{}
+}
+
+// arraySeq adapts an evaluated array to sequence
+type arraySeq []interface{}
+
+func (a arraySeq) Len() int                { return len(a) }
+func (a arraySeq) Index(i int) interface{} { return a[i] }
+
+// asSequence returns val as a sequence if it is an array or a range
+func asSequence(val interface{}) (sequence, bool) {
+	switch v := val.(type) {
+	case []interface{}:
+		return arraySeq(v), true
+	case RangeResult:
+		return v, true
+	}
+	return nil, false
+}
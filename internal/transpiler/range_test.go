@@ -0,0 +1,98 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNewRange_Bounds(t *testing.T) {
+	tests := []struct {
+		name             string
+		start, end, step int64
+		want             []int64
+	}{
+		{"forward", 0, 4, 2, []int64{0, 2, 4}},
+		{"backward", 3, 0, -1, []int64{3, 2, 1, 0}},
+		{"uneven step", 0, 5, 2, []int64{0, 2, 4}},
+		{"step away from end", 0, 5, -1, nil},
+		{"max int64 edge", math.MaxInt64 - 2, math.MaxInt64, 1, []int64{math.MaxInt64 - 2, math.MaxInt64 - 1, math.MaxInt64}},
+		{"min int64 edge", math.MinInt64 + 1, math.MinInt64, -1, []int64{math.MinInt64 + 1, math.MinInt64}},
+		{"full span", math.MinInt64, math.MaxInt64, math.MaxInt64, []int64{math.MinInt64, -1, math.MaxInt64 - 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, ok := newRange(tt.start, tt.end, tt.step)
+			if !ok {
+				t.Fatalf("Range %d..%d step %d reported too large", tt.start, tt.end, tt.step)
+			}
+			if rr.Len() != len(tt.want) {
+				t.Fatalf("Expected %d values, got %d", len(tt.want), rr.Len())
+			}
+			for i, want := range tt.want {
+				if got := rr.At(i); got != want {
+					t.Errorf("At(%d) = %d, want %d", i, got, want)
+				}
+			}
+		})
+	}
+
+	if _, ok := newRange(math.MinInt64, math.MaxInt64, 1); ok {
+		t.Error("Expected a range of 2^64 values to be too large")
+	}
+}
+
+func TestRange_TopLevelEncodesAsArray(t *testing.T) {
+	input := "x = 0..2\nr := 3..1 step -1\ny = r"
+	tests := []struct {
+		name string
+		run  func(*Transpiler) ([]byte, error)
+		want []string
+	}{
+		{"json", (*Transpiler).Transpile, []string{"\"x\": [\n    0,\n    1,\n    2\n  ]", "\"y\": [\n    3,\n    2,\n    1\n  ]"}},
+		{"yaml", (*Transpiler).TranspileToYAML, []string{"x:\n    - 0\n    - 1\n    - 2", "\"y\":\n    - 3\n    - 2\n    - 1"}},
+		{"toml", (*Transpiler).TranspileToTOML, []string{"x = [0, 1, 2]", "y = [3, 2, 1]"}},
+		{"typescript", (*Transpiler).TranspileToTypeScript, []string{"export const x = [\n  0,\n  1,\n  2\n] as const;"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.run(newTestTranspiler(t, input))
+			if err != nil {
+				t.Fatalf("Transpile error: %v", err)
+			}
+			if strings.Contains(string(out), "Values") {
+				t.Fatalf("Range leaked its internals into the output:\n%s", out)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRange_NestedArraysStayNested(t *testing.T) {
+	root := transpileToMap(t, "x = [[0..2], [3..4], 5..6]")
+	got, _ := json.Marshal(root["x"])
+	if string(got) != "[[0,1,2],[3,4],5,6]" {
+		t.Errorf("Expected [[0,1,2],[3,4],5,6], got %s", got)
+	}
+}
+
+func TestRange_HugeRangeOnlyCountsItsLength(t *testing.T) {
+	// Mapping a huge range trips the element limit before anything is generated
+	tr := newTestTranspiler(t, "x = (0..999999999999 map (a) = a)", withLimits(Limits{MaxElements: 1000}))
+	if _, err := tr.Transpile(); err == nil || !strings.Contains(err.Error(), "more than 1000 elements") {
+		t.Fatalf("Expected the element limit to trip, got %v", err)
+	}
+
+	// A range that fits in no int is rejected instead of wrapping around
+	tr = newTestTranspiler(t, "x = -9223372036854775807..9223372036854775807")
+	if _, err := tr.Transpile(); err == nil || !strings.Contains(err.Error(), "more values than gremlin can count") {
+		t.Fatalf("Expected a range-too-large error, got %v", err)
+	}
+}
@@ -0,0 +1,444 @@
+package transpiler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// IncludeResolver locates and reads the files named by include statements and
+// load() calls. Names returned by Resolve and Glob are opaque to the
+// transpiler: they key the include cache and cycle detection, and are passed
+// back to ReadFile, Stat and Dir.
+type IncludeResolver interface {
+	// Resolve turns a path written in the source, relative to baseDir, into a file name
+	Resolve(baseDir, name string) (string, error)
+	// Glob returns the file names matching pattern relative to baseDir
+	Glob(baseDir, pattern string) ([]string, error)
+	// Stat describes a resolved file name
+	Stat(name string) (fs.FileInfo, error)
+	// ReadFile returns the contents of a resolved file name
+	ReadFile(name string) ([]byte, error)
+	// Dir returns the base directory for includes made from inside name
+	Dir(name string) string
+}
+
+// OSResolver resolves includes on the local filesystem. This is the default.
+// With Root set it is a sandbox: every file must live under Root, both as
+// written and after following symlinks. Files are opened through an os.Root,
+// so a symlink swapped in after a name was resolved still can't lead out.
+type OSResolver struct {
+	Root string
+}
+
+func (r OSResolver) Resolve(baseDir, name string) (string, error) {
+	var full string
+	if filepath.IsAbs(name) {
+		full = filepath.Clean(name)
+	} else {
+		full = filepath.Clean(filepath.Join(baseDir, name))
+	}
+	if _, err := r.rel(full); err != nil {
+		return "", err
+	}
+	return full, nil
+}
+
+func (r OSResolver) Glob(baseDir, pattern string) ([]string, error) {
+	abs, err := r.Resolve(baseDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(abs)
+	if err != nil {
+		return nil, err
+	}
+	// Report matches that only exist on the far side of a symlink; reading
+	// them would fail anyway
+	for _, m := range matches {
+		if _, err := r.Stat(m); isSandboxError(err) {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+func (r OSResolver) Stat(name string) (fs.FileInfo, error) {
+	if r.Root == "" {
+		return os.Stat(name)
+	}
+	root, rel, err := r.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	info, err := root.Stat(rel)
+	if err != nil {
+		return nil, r.explain(name, err)
+	}
+	return info, nil
+}
+
+func (r OSResolver) ReadFile(name string) ([]byte, error) {
+	if r.Root == "" {
+		return os.ReadFile(name)
+	}
+	root, rel, err := r.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	data, err := root.ReadFile(rel)
+	if err != nil {
+		return nil, r.explain(name, err)
+	}
+	return data, nil
+}
+
+func (OSResolver) Dir(name string) string { return filepath.Dir(name) }
+
+// rel returns name relative to Root, or a SandboxError if it lies outside
+// Root as written. Without a Root every name is allowed.
+func (r OSResolver) rel(name string) (string, error) {
+	if r.Root == "" {
+		return name, nil
+	}
+	root, err := filepath.Abs(r.Root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	if !within(root, abs) {
+		return "", &SandboxError{Path: name, Root: r.Root}
+	}
+	return filepath.Rel(root, abs)
+}
+
+// open opens Root for one access to name, returning name relative to it
+func (r OSResolver) open(name string) (*os.Root, string, error) {
+	rel, err := r.rel(name)
+	if err != nil {
+		return nil, "", err
+	}
+	root, err := os.OpenRoot(r.Root)
+	if err != nil {
+		return nil, "", err
+	}
+	return root, rel, nil
+}
+
+// explain turns an error from accessing name through Root into a SandboxError
+// when a symlink leads out of Root. os.Root has already refused the access;
+// this only decides how to word the error.
+func (r OSResolver) explain(name string, err error) error {
+	realRoot, rootErr := filepath.EvalSymlinks(r.Root)
+	abs, absErr := filepath.Abs(name)
+	if rootErr != nil || absErr != nil {
+		return err
+	}
+	if realRoot, rootErr = filepath.Abs(realRoot); rootErr != nil {
+		return err
+	}
+	if real, realErr := evalExistingSymlinks(abs); realErr == nil && !within(realRoot, real) {
+		return &SandboxError{Path: name, Root: r.Root, Symlink: true}
+	}
+	return err
+}
+
+// evalExistingSymlinks resolves symlinks in the longest existing prefix of
+// path and appends the rest unchanged
+func evalExistingSymlinks(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return real, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	realParent, err := evalExistingSymlinks(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(realParent, filepath.Base(path)), nil
+}
+
+// within reports whether path is root or lies below it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// SandboxError reports a file outside the sandbox root of an OSResolver
+type SandboxError struct {
+	Path    string
+	Root    string
+	Symlink bool // the path is inside Root as written but a symlink leads out
+}
+
+func (e *SandboxError) Error() string {
+	if e.Symlink {
+		return fmt.Sprintf("%s leads outside the sandbox root %s through a symlink", e.Path, e.Root)
+	}
+	return fmt.Sprintf("%s is outside the sandbox root %s", e.Path, e.Root)
+}
+
+// FSResolver resolves includes inside an fs.FS such as an embed.FS. Names are
+// slash-separated and rooted at the top of the FS; they can't climb out of it.
+type FSResolver struct {
+	FS fs.FS
+}
+
+// NewMapResolver returns a resolver over in-memory files keyed by path, e.g.
+// {"db.jsson": "host = \"localhost\""}. The WASM playground uses it.
+func NewMapResolver(files map[string]string) *FSResolver {
+	mfs := mapFS{}
+	for name, content := range files {
+		mfs[path.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))] = []byte(content)
+	}
+	return &FSResolver{FS: mfs}
+}
+
+// mapFS is a read-only fs.FS over in-memory files keyed by clean slash path.
+// Directories exist implicitly above the files.
+type mapFS map[string][]byte
+
+func (m mapFS) Open(name string) (fs.File, error) {
+	info, err := m.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries, err := m.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &mapDir{info: info, entries: entries}, nil
+	}
+	return &mapFile{Reader: bytes.NewReader(m[name]), info: info}, nil
+}
+
+func (m mapFS) ReadFile(name string) ([]byte, error) {
+	info, err := m.stat("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return slices.Clone(m[name]), nil
+}
+
+func (m mapFS) Stat(name string) (fs.FileInfo, error) { return m.stat("stat", name) }
+
+func (m mapFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, err := m.stat("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for file, data := range m {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+		child, _, nested := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		info := mapInfo{name: child, dir: nested}
+		if !nested {
+			info.size = int64(len(data))
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+func (m mapFS) stat(op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if data, ok := m[name]; ok {
+		return mapInfo{name: path.Base(name), size: int64(len(data))}, nil
+	}
+	for file := range m {
+		if name == "." || strings.HasPrefix(file, name+"/") {
+			return mapInfo{name: path.Base(name), dir: true}, nil
+		}
+	}
+	if name == "." {
+		// The root exists even with no files in it
+		return mapInfo{name: ".", dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// mapInfo describes a mapFS file or directory
+type mapInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i mapInfo) Name() string       { return i.name }
+func (i mapInfo) Size() int64        { return i.size }
+func (i mapInfo) ModTime() time.Time { return time.Time{} }
+func (i mapInfo) IsDir() bool        { return i.dir }
+func (i mapInfo) Sys() any           { return nil }
+
+func (i mapInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// mapFile is an open mapFS file
+type mapFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *mapFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *mapFile) Close() error               { return nil }
+
+// mapDir is an open mapFS directory
+type mapDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *mapDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *mapDir) Close() error               { return nil }
+
+func (d *mapDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *mapDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	return rest, nil
+}
+
+func (r *FSResolver) Resolve(baseDir, name string) (string, error) {
+	name = filepath.ToSlash(name)
+	var full string
+	if strings.HasPrefix(name, "/") {
+		full = path.Clean(strings.TrimPrefix(name, "/"))
+	} else {
+		full = path.Join(filepath.ToSlash(baseDir), name)
+	}
+	if full == "" {
+		full = "."
+	}
+	if !fs.ValidPath(full) {
+		return "", fmt.Errorf("%q is outside the include filesystem", name)
+	}
+	return full, nil
+}
+
+func (r *FSResolver) Glob(baseDir, pattern string) ([]string, error) {
+	full, err := r.Resolve(baseDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Glob(r.FS, full)
+}
+
+func (r *FSResolver) Stat(name string) (fs.FileInfo, error) { return fs.Stat(r.FS, name) }
+
+func (r *FSResolver) ReadFile(name string) ([]byte, error) { return fs.ReadFile(r.FS, name) }
+
+func (r *FSResolver) Dir(name string) string { return path.Dir(name) }
+
+// SearchPathResolver looks an include up next to the including file first and
+// then in each search path in order, like a C compiler's -I flags.
+type SearchPathResolver struct {
+	Base  IncludeResolver
+	Paths []string
+}
+
+func (r *SearchPathResolver) Resolve(baseDir, name string) (string, error) {
+	first, err := r.Base.Resolve(baseDir, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.Base.Stat(first); err == nil {
+		return first, nil
+	}
+	for _, dir := range r.Paths {
+		candidate, err := r.Base.Resolve(dir, name)
+		if err != nil {
+			continue
+		}
+		if _, err := r.Base.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	// Nothing found: report the error against the local path
+	return first, nil
+}
+
+func (r *SearchPathResolver) Glob(baseDir, pattern string) ([]string, error) {
+	matches, err := r.Base.Glob(baseDir, pattern)
+	if err != nil || len(matches) > 0 {
+		return matches, err
+	}
+	for _, dir := range r.Paths {
+		matches, err := r.Base.Glob(dir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			return matches, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *SearchPathResolver) Stat(name string) (fs.FileInfo, error) { return r.Base.Stat(name) }
+
+func (r *SearchPathResolver) ReadFile(name string) ([]byte, error) { return r.Base.ReadFile(name) }
+
+func (r *SearchPathResolver) Dir(name string) string { return r.Base.Dir(name) }
+
+func isSandboxError(err error) bool {
+	var sandboxErr *SandboxError
+	return errors.As(err, &sandboxErr)
+}
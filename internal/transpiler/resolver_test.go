@@ -0,0 +1,220 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func transpileWithResolver(t *testing.T, r IncludeResolver, baseDir, input string) (map[string]interface{}, error) {
+	t.Helper()
+	output, err := newTestTranspiler(t, input, func(tr *Transpiler) {
+		tr.baseDir = baseDir
+		tr.SetResolver(r)
+	}).Transpile()
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(output, &root); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	return root, nil
+}
+
+func TestResolver_MapFiles(t *testing.T) {
+	r := NewMapResolver(map[string]string{
+		"db.jsson":              "include \"shared/defaults.jsson\"\nhost = \"localhost\"\n",
+		"shared/defaults.jsson": "port = 5432\n",
+		"services/a.jsson":      "a = 1\n",
+		"services/b.jsson":      "b = 2\n",
+		"users.csv":             "id,name\n1,Ana\n",
+	})
+	input := `
+include "db.jsson" as database
+include "services" keyed
+users = load("users.csv")
+`
+	root, err := transpileWithResolver(t, r, ".", input)
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+
+	db := root["database"].(map[string]interface{})
+	if db["host"] != "localhost" || db["port"] != float64(5432) {
+		t.Errorf("Unexpected nested in-memory include: %v", db)
+	}
+	if _, ok := root["a"].(map[string]interface{}); !ok {
+		t.Errorf("Expected directory include from memory, got %v", root)
+	}
+	if users := root["users"].([]interface{}); len(users) != 1 {
+		t.Errorf("Expected load() through the resolver, got %v", users)
+	}
+}
+
+func TestResolver_MapFSConforms(t *testing.T) {
+	r := NewMapResolver(map[string]string{
+		"db.jsson":              "host = \"localhost\"\n",
+		"shared/defaults.jsson": "port = 5432\n",
+		"shared/deep/x.jsson":   "x = 1\n",
+	})
+	if err := fstest.TestFS(r.FS, "db.jsson", "shared/defaults.jsson", "shared/deep/x.jsson"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolver_FSStaysInsideRoot(t *testing.T) {
+	r := &FSResolver{FS: fstest.MapFS{"a.jsson": {Data: []byte("a = 1\n")}}}
+	_, err := transpileWithResolver(t, r, ".", `include "../secret.jsson"`)
+	if err == nil || !strings.Contains(err.Error(), "outside the include filesystem") {
+		t.Fatalf("Expected escape to be rejected, got %v", err)
+	}
+}
+
+func TestResolver_SearchPaths(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	app := filepath.Join(dir, "app")
+	for _, d := range []string{lib, app} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(lib, "common.jsson"), []byte("shared = true\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(app, "common.jsson"), []byte("local = true\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(lib, "extra.jsson"), []byte("extra = true\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	r := &SearchPathResolver{Base: OSResolver{}, Paths: []string{lib}}
+	root, err := transpileWithResolver(t, r, app, "include \"common.jsson\"\ninclude \"extra.jsson\"")
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	// The file next to the source wins; the search path fills in the rest
+	if root["local"] != true || root["shared"] != nil || root["extra"] != true {
+		t.Fatalf("Unexpected search path resolution: %v", root)
+	}
+}
+
+func TestResolver_SandboxRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	outside := filepath.Join(dir, "secret.jsson")
+	if err := os.WriteFile(outside, []byte("secret = 1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.json"), []byte(`{"secret": 1}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", "ok.jsson"), []byte("ok = true\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link.jsson")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(dir, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	r := OSResolver{Root: root}
+	if got, err := transpileWithResolver(t, r, root, `include "sub/ok.jsson"`); err != nil || got["ok"] != true {
+		t.Fatalf("Expected include inside the sandbox to work, got %v, %v", got, err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`include "../secret.jsson"`, "is outside the sandbox root"},
+		{`include "` + outside + `"`, "is outside the sandbox root"},
+		{`include "link.jsson"`, "through a symlink"},
+		{`include "escape/secret.jsson"`, "through a symlink"},
+		{`include "escape/*.jsson"`, "through a symlink"},
+		{`x = load("../secret.json")`, "is outside the sandbox root"},
+		{`x = load("escape/secret.json")`, "through a symlink"},
+	}
+	for _, tt := range tests {
+		_, err := transpileWithResolver(t, r, root, tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "stays inside the sandbox") {
+			t.Errorf("%s: expected sandbox error containing %q, got %v", tt.input, tt.want, err)
+		}
+	}
+}
+
+func TestResolver_SandboxChecksAtOpen(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	outside := filepath.Join(dir, "secret.jsson")
+	if err := os.WriteFile(outside, []byte("secret = 1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	inside := filepath.Join(root, "a.jsson")
+	if err := os.WriteFile(inside, []byte("a = 1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	r := OSResolver{Root: root}
+	name, err := r.Resolve(root, "a.jsson")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	// Swap the file for a symlink out of the sandbox after it was resolved
+	if err := os.Remove(inside); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := os.Symlink(outside, inside); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if data, err := r.ReadFile(name); err == nil {
+		t.Fatalf("Expected the swapped symlink to be refused, read %q", data)
+	} else if !isSandboxError(err) {
+		t.Errorf("Expected a sandbox error, got %v", err)
+	}
+}
+
+func TestIncludeLimits(t *testing.T) {
+	r := NewMapResolver(map[string]string{
+		"a.jsson":   "include \"b.jsson\"\na = 1\n",
+		"b.jsson":   "include \"c.jsson\"\nb = 1\n",
+		"c.jsson":   "c = 1\n",
+		"data.json": "{}",
+	})
+	parse := func(input string) *Transpiler {
+		return newTestTranspiler(t, input, func(tr *Transpiler) {
+			tr.baseDir = "."
+			tr.SetResolver(r)
+		})
+	}
+
+	tr := parse(`include "a.jsson"`)
+	tr.SetIncludeLimits(2, 0)
+	if _, err := tr.Transpile(); err == nil || !strings.Contains(err.Error(), "deeper than the limit of 2") {
+		t.Errorf("Expected depth limit error, got %v", err)
+	}
+
+	tr = parse(`include "a.jsson"`)
+	tr.SetIncludeLimits(3, 0)
+	if _, err := tr.Transpile(); err != nil {
+		t.Errorf("Expected depth 3 to be allowed, got %v", err)
+	}
+
+	tr = parse("include \"a.jsson\"\nd = load(\"data.json\")")
+	tr.SetIncludeLimits(0, 3)
+	if _, err := tr.Transpile(); err == nil || !strings.Contains(err.Error(), "more than 3 included or loaded files") {
+		t.Errorf("Expected file count limit error, got %v", err)
+	}
+}
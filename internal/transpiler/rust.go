@@ -0,0 +1,145 @@
+package transpiler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// rustKeywords can't be used as field names
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true, "continue": true,
+	"crate": true, "dyn": true, "else": true, "enum": true, "extern": true, "false": true,
+	"fn": true, "for": true, "if": true, "impl": true, "in": true, "let": true, "loop": true,
+	"match": true, "mod": true, "move": true, "mut": true, "pub": true, "ref": true,
+	"return": true, "self": true, "static": true, "struct": true, "super": true, "trait": true,
+	"true": true, "type": true, "unsafe": true, "use": true, "where": true, "while": true,
+	"abstract": true, "become": true, "box": true, "do": true, "final": true, "macro": true,
+	"override": true, "priv": true, "try": true, "typeof": true, "unsized": true,
+	"virtual": true, "yield": true,
+}
+
+// rustNames are types a generated module refers to, which structs can't take
+var rustNames = []string{"Box", "Deserialize", "Option", "Result", "Self", "Serialize", "String", "Value", "Vec"}
+
+// rustEmptyName stands in for a key with nothing to spell a field name from
+const rustEmptyName = "field"
+
+// rustAnyValue holds a value whose type varies
+const rustAnyValue = "serde_json::Value"
+
+// TranspileToRust writes serde structs inferred from the evaluated document,
+// ready to deserialize the JSON output into
+func (t *Transpiler) TranspileToRust() ([]byte, error) {
+	return t.TranspileToRustContext(context.Background())
+}
+
+// TranspileToRustContext is TranspileToRust that stops with ctx's error once
+// ctx is done
+func (t *Transpiler) TranspileToRustContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := t.inferTypeModel(ctx, root, rustNames...)
+	if err != nil {
+		return t.limitOutput(ctx, nil, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Generated by jsson. Do not edit.\n\n")
+	buf.WriteString("use serde::{Deserialize, Serialize};\n")
+	for _, r := range m.types {
+		buf.WriteString("\n#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]\n")
+		buf.WriteString(fmt.Sprintf("pub struct %s {\n", r.name))
+		names := make(map[string]bool)
+		for _, f := range r.fields() {
+			writeLineComments(&buf, f.doc, "    ///")
+			name := snakeIdent(f.key)
+			switch {
+			case name == "":
+				name = rustEmptyName
+			case rustKeywords[name]:
+				name += "_"
+			}
+			name = uniqueIdent(names, name)
+
+			typ := m.rustType(f.shape)
+			if name != f.key {
+				buf.WriteString(fmt.Sprintf("    #[serde(rename = %s)]\n", rustQuote(f.key)))
+			}
+			if f.optional {
+				// Keys missing from the source stay missing when written back
+				buf.WriteString("    #[serde(default, skip_serializing_if = \"Option::is_none\")]\n")
+				if !strings.HasPrefix(typ, "Option<") {
+					typ = "Option<" + typ + ">"
+				}
+			}
+			buf.WriteString(fmt.Sprintf("    pub %s: %s,\n", name, typ))
+		}
+		buf.WriteString("}\n")
+	}
+	return t.limitOutput(ctx, buf.Bytes(), nil)
+}
+
+// rustType spells the type of a position. A value that is sometimes null
+// becomes an Option; one whose type varies is left to serde_json.
+func (m *typeModel) rustType(s *shape) string {
+	types, nullable := s.valueTypes()
+	if len(types) != 1 {
+		// Mixed values, or only ever null: Value holds null too
+		return rustAnyValue
+	}
+	var typ string
+	switch types[0] {
+	case "object":
+		typ = m.names[s]
+	case "array":
+		elem := rustAnyValue
+		if s.items != nil {
+			elem = m.rustType(s.items)
+		}
+		typ = "Vec<" + elem + ">"
+	case "string":
+		typ = "String"
+	case "integer":
+		typ = "i64"
+	case "number":
+		typ = "f64"
+	case "boolean":
+		typ = "bool"
+	}
+	if nullable {
+		return "Option<" + typ + ">"
+	}
+	return typ
+}
+
+// rustQuote writes s as a Rust string literal, which spells escapes as \u{..}
+func rustQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\u{%x}`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
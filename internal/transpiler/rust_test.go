@@ -0,0 +1,79 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRust_StructsFromValues(t *testing.T) {
+	input := `/// Service name
+name = "api"
+users = [
+  { id = 1, apiUrl = "a", email = "a@x.io", type = "x" }
+  { id = 2, apiUrl = "b", type = "y" }
+  { id = 3, apiUrl = "c", email = null, type = "z", tags = [ "x", 1 ] }
+]
+ratio = 0.5
+nothing = null`
+	out := generate(t, input, nil, nil, (*Transpiler).TranspileToRust)
+
+	want := `// Generated by jsson. Do not edit.
+
+use serde::{Deserialize, Serialize};
+
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct User {
+    #[serde(rename = "apiUrl")]
+    pub api_url: String,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub email: Option<String>,
+    pub id: i64,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub tags: Option<Vec<serde_json::Value>>,
+    #[serde(rename = "type")]
+    pub type_: String,
+}
+
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Config {
+    /// Service name
+    pub name: String,
+    pub nothing: serde_json::Value,
+    pub ratio: f64,
+    pub users: Vec<User>,
+}
+`
+	if out != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestRust_ReservedNames(t *testing.T) {
+	files := map[string]string{"data.json": "{\"2fa\": true, \"odd\\\"key\\u0001\": 1, \"option\": {\"on\": true}}"}
+	out := generate(t, `data = load("data.json")`, files, nil, (*Transpiler).TranspileToRust)
+
+	for _, want := range []string{
+		"    #[serde(rename = \"2fa\")]\n    pub x2fa: bool,\n",
+		"    #[serde(rename = \"odd\\\"key\\u{1}\")]\n    pub odd_key: i64,\n",
+		"pub struct DataOption {",
+		"    pub option: DataOption,\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRust_EmptyKeysGetNamedFields(t *testing.T) {
+	files := map[string]string{"data.json": `{"": 1, "type": "x"}`}
+	out := generate(t, `data = load("data.json")`, files, nil, (*Transpiler).TranspileToRust)
+
+	for _, want := range []string{
+		"    #[serde(rename = \"\")]\n    pub field: i64,\n",
+		"    #[serde(rename = \"type\")]\n    pub type_: String,\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package transpiler
+
+// Scope holds the local variables visible to an expression: map iterators,
+// template map parameters and object-local declarations. It is a persistent
+// chain with one binding per link: Bind returns a new innermost link and never
+// changes the scope it was called on. Entering a map iteration or an object
+// therefore costs one small allocation per variable instead of a copy of every
+// variable in view, and a parent can be shared freely, including by concurrent
+// map workers. Globals live in the transpiler's symbol table, so chains stay short.
+type Scope struct {
+	parent *Scope
+	name   string
+	value  interface{}
+}
+
+// Bind returns a scope with name bound to val on top of s, shadowing any
+// outer binding of the same name. s may be nil (the empty scope).
+func (s *Scope) Bind(name string, val interface{}) *Scope {
+	return &Scope{parent: s, name: name, value: val}
+}
+
+// Get looks name up from the innermost binding outwards
+func (s *Scope) Get(name string) (interface{}, bool) {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return s.value, true
+		}
+	}
+	return nil, false
+}
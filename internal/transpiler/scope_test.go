@@ -0,0 +1,45 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScope_BindDoesNotChangeParent(t *testing.T) {
+	outer := (*Scope)(nil).Bind("x", 1)
+	inner := outer.Bind("x", 2).Bind("y", 3)
+
+	if v, _ := outer.Get("x"); v != 1 {
+		t.Errorf("Expected outer x to stay 1, got %v", v)
+	}
+	if _, ok := outer.Get("y"); ok {
+		t.Errorf("Expected y to be invisible from the outer scope")
+	}
+	if v, _ := inner.Get("x"); v != 2 {
+		t.Errorf("Expected inner x to shadow the outer one, got %v", v)
+	}
+	if _, ok := (*Scope)(nil).Get("x"); ok {
+		t.Errorf("Expected the nil scope to be empty")
+	}
+}
+
+func TestScope_ShadowingInMapsAndObjects(t *testing.T) {
+	input := `
+x := 100
+rows = (0..2 map (x) = { x := x * 10, y := x + 1, v = y, nested = (0..1 map (y) = x + y) })
+after = x
+`
+	output, err := newTestTranspiler(t, input).Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	out := strings.Join(strings.Fields(string(output)), "")
+	for _, want := range []string{
+		`{"nested":[20,21],"v":21}`,
+		`"after":100`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %s, got %s", want, out)
+		}
+	}
+}
@@ -123,3 +123,47 @@ func BenchmarkNestedMap_SmallMatrix(b *testing.B) {
 		}
 	}
 }
+
+// Benchmark nested map (100x100 matrix, as in TestStress_LargeMatrix_100x100)
+func BenchmarkNestedMap_LargeMatrix(b *testing.B) {
+	input := `matrix = (0..99 map (y) = (0..99 map (x) = x * y))`
+
+	for i := 0; i < b.N; i++ {
+		l := lexer.New(input)
+		p := parser.New(l)
+		prog := p.ParseProgram()
+
+		tr := New(prog, "", "keep", "")
+		tr.SetStreamingMode(false, 100000)
+
+		_, err := tr.Transpile()
+		if err != nil {
+			b.Fatalf("Transpile error: %v", err)
+		}
+	}
+}
+
+// Benchmark nested maps under object-local variables, where each level adds to the scope
+func BenchmarkNestedMap_DeepScope(b *testing.B) {
+	input := `grid {
+  a := 1
+  b := 2
+  c := 3
+  d := 4
+  cells = (0..49 map (y) = { row := y * a, cols = (0..49 map (x) = { v = x + row + b + c + d }) })
+}`
+
+	for i := 0; i < b.N; i++ {
+		l := lexer.New(input)
+		p := parser.New(l)
+		prog := p.ParseProgram()
+
+		tr := New(prog, "", "keep", "")
+		tr.SetStreamingMode(false, 100000)
+
+		_, err := tr.Transpile()
+		if err != nil {
+			b.Fatalf("Transpile error: %v", err)
+		}
+	}
+}
@@ -2,125 +2,104 @@ package transpiler
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"fmt"
 	"jsson/internal/ast"
 	ie "jsson/internal/errors"
-	"jsson/internal/lexer"
-	"jsson/internal/parser"
-	"os"
-	"path/filepath"
 
 	"github.com/BurntSushi/toml"
 )
 
 // TranspileToTOML converts the transpiled data to TOML format
 func (t *Transpiler) TranspileToTOML() ([]byte, error) {
+	return t.TranspileToTOMLContext(context.Background())
+}
+
+// TranspileToTOMLContext is TranspileToTOML that stops with ctx's error once ctx is done
+func (t *Transpiler) TranspileToTOMLContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
 	// First, transpile to the internal representation
-	root := make(map[string]interface{})
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, stmt := range t.program.Statements {
-		switch s := stmt.(type) {
-		case *ast.VariableDeclaration:
-			// Variable declarations are stored in symbol table but not added to output
-			val, err := t.evalExpression(s.Value, nil)
-			if err != nil {
-				return nil, err
-			}
-			t.symbolTable[s.Name.Value] = val
-		case *ast.AssignmentStatement:
-			key := s.Name.Value
-			val, err := t.evalExpression(s.Value, nil)
-			if err != nil {
-				return nil, err
-			}
-			root[key] = val
-		case *ast.IncludeStatement:
-			// Handle includes (same logic as JSON transpiler)
-			includePath := s.Path.Value
-			var includeAbs string
-			if filepath.IsAbs(includePath) {
-				includeAbs = filepath.Clean(includePath)
-			} else {
-				includeAbs = filepath.Clean(filepath.Join(t.baseDir, includePath))
-			}
+	// TOML has no null: drop null keys and reject nulls that can't be dropped
+	tomlRoot, err := t.stripTOMLNulls(root, "", nil)
+	if err != nil {
+		return nil, err
+	}
 
-			if t.inProgress[includeAbs] {
-				return nil, t.errfNodeMsg(s, ie.CyclicInclude(includeAbs))
-			}
+	// Marshal to TOML
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
+	if err := encoder.Encode(tomlRoot); err != nil {
+		return t.limitOutput(ctx, nil, err)
+	}
+	if docs := t.collectDocs(); len(docs) > 0 {
+		return t.limitOutput(ctx, insertTOMLDocs(buf.Bytes(), docs), nil)
+	}
+	return t.limitOutput(ctx, buf.Bytes(), nil)
+}
 
-			if cached, ok := t.includeCache[includeAbs]; ok {
-				for k, v := range cached {
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				}
-				break
+// stripTOMLNulls prepares a value for TOML encoding. TOML has no null value, so
+// keys holding null are omitted from their table, while null array elements
+// (which cannot be omitted without shifting indexes) are reported as errors at
+// node, the assignment the value comes from.
+func (t *Transpiler) stripTOMLNulls(value interface{}, path string, node ast.Node) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if val == nil {
+				continue
 			}
-
-			t.inProgress[includeAbs] = true
-
-			data, err := os.ReadFile(includeAbs)
+			at := node
+			if path == "" {
+				at = t.assignmentNode(k)
+			}
+			stripped, err := t.stripTOMLNulls(val, joinPath(path, k), at)
 			if err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "could not read include file %q — gremlin can't find it: %v", s.Path.Value, err)
+				return nil, err
 			}
-
-			l := lexer.New(string(data))
-			l.SetSourceFile(includeAbs)
-			p := parser.New(l)
-			prog := p.ParseProgram()
-			if len(p.Errors()) > 0 {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "parser errors in included file %q — wizard got confused: %v", s.Path.Value, p.Errors())
+			out[k] = stripped
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			if val == nil {
+				return nil, t.errfNodeMsg(node, ie.NullInTOMLArray(fmt.Sprintf("%s[%d]", path, i)))
 			}
-
-			incBase := filepath.Dir(includeAbs)
-			incT := New(prog, incBase, t.mergeMode, includeAbs)
-			incT.includeCache = t.includeCache
-			incT.inProgress = t.inProgress
-
-			incJSON, err := incT.Transpile()
+			stripped, err := t.stripTOMLNulls(val, fmt.Sprintf("%s[%d]", path, i), node)
 			if err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "transpile error in included file %q: %v", s.Path.Value, err)
-			}
-
-			var incRoot map[string]interface{}
-			if err := json.Unmarshal(incJSON, &incRoot); err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "invalid json from include %q: %v", s.Path.Value, err)
+				return nil, err
 			}
+			out[i] = stripped
+		}
+		return out, nil
+	}
+	return value, nil
+}
 
-			t.includeCache[includeAbs] = incRoot
-			t.inProgress[includeAbs] = false
-
-			for k, v := range incRoot {
-				switch t.mergeMode {
-				case "keep":
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				case "overwrite":
-					root[k] = v
-				case "error":
-					if _, exists := root[k]; exists {
-						return nil, t.errfNode(s, "include merge conflict for key %q from %s", k, includeAbs)
-					}
-					root[k] = v
-				default:
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				}
-			}
+// assignmentNode returns the top-level assignment that sets key, or nil for
+// keys that come from includes
+func (t *Transpiler) assignmentNode(key string) ast.Node {
+	var node ast.Node
+	for _, stmt := range t.program.Statements {
+		// The last assignment to a key is the one in the output
+		if s, ok := stmt.(*ast.AssignmentStatement); ok && s.Name.Value == key {
+			node = s
 		}
 	}
+	return node
+}
 
-	// Marshal to TOML
-	var buf bytes.Buffer
-	encoder := toml.NewEncoder(&buf)
-	if err := encoder.Encode(root); err != nil {
-		return nil, err
+// joinPath appends key to a dotted output path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
 	}
-	return buf.Bytes(), nil
+	return path + "." + key
 }
@@ -1,37 +1,67 @@
 package transpiler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"jsson/internal/ast"
 	ie "jsson/internal/errors"
 	"jsson/internal/lexer"
 	"jsson/internal/parser"
 	"jsson/internal/token"
-	"os"
+	"math"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-// RangeResult wraps a slice generated by a range expression
-// This allows ArrayLiteral to distinguish between a range (to be flattened) and a nested array
-type RangeResult struct {
-	Values []interface{}
-}
-
 type Transpiler struct {
 	program *ast.Program
 	baseDir string
 	// includeCache stores previously transpiled include outputs keyed by absolute path
 	includeCache map[string]map[string]interface{}
+	// includeDocs stores the doc comments of included files keyed by absolute path
+	includeDocs map[string]map[string]string
 	// inProgress marks includes currently being processed to detect cycles
 	inProgress map[string]bool
+	// loadCache stores data files read by load() keyed by resolved name
+	loadCache map[string]interface{}
+	// resolver locates and reads included files and load() data
+	resolver IncludeResolver
+	// Include limits (0 = unlimited); filesRead is shared with child transpilers
+	maxIncludeDepth int
+	maxIncludeFiles int
+	includeDepth    int
+	filesRead       *int
+	// budget enforces Limits (nil = unlimited); shared with child transpilers
+	budget *budget
+	// depth is the current expression nesting, checked against Limits.MaxDepth
+	depth int
+	// runCtx is the context of the current top-level run; lazy ranges keep it
+	// so encoding them can be cancelled too
+	runCtx context.Context
+	// schema, if set, is checked against the document before it's encoded
+	schema *Schema
+	// types holds the program's type declarations, compiled to schemas
+	types map[string]*Schema
+	// tsMode selects the TypeScript output, one of the TypeScript* modes
+	tsMode string
+	// goPackage is the package clause of generated Go code
+	goPackage string
+	// pyMode selects the Python output, one of the Python* modes
+	pyMode string
+	// parallelism is the number of workers used for large maps (0 or 1 = sequential)
+	parallelism int
 	// mergeMode controls include merge behavior: "keep" (default), "overwrite", "error"
 	mergeMode string
 	// sourceFile is the path to the source file being transpiled (optional)
 	sourceFile string
 	// symbolTable stores variable declarations (name := value)
 	symbolTable map[string]interface{}
+	// decimalMode evaluates float literals as exact decimals instead of float64
+	decimalMode bool
 	// Streaming support
 	streamingEnabled bool
 	streamThreshold  int64 // Auto-enable streaming if range size > threshold
@@ -45,7 +75,11 @@ func New(program *ast.Program, baseDir string, mergeMode string, sourceFile stri
 		program:          program,
 		baseDir:          baseDir,
 		includeCache:     make(map[string]map[string]interface{}),
+		includeDocs:      make(map[string]map[string]string),
 		inProgress:       make(map[string]bool),
+		loadCache:        make(map[string]interface{}),
+		resolver:         OSResolver{},
+		filesRead:        new(int),
 		mergeMode:        mergeMode,
 		sourceFile:       sourceFile,
 		symbolTable:      make(map[string]interface{}),
@@ -54,243 +88,402 @@ func New(program *ast.Program, baseDir string, mergeMode string, sourceFile stri
 	}
 }
 
+// Transpile evaluates the program and encodes the result as indented JSON
 func (t *Transpiler) Transpile() ([]byte, error) {
+	return t.TranspileContext(context.Background())
+}
+
+// TranspileContext is Transpile that stops with ctx's error once ctx is done
+func (t *Transpiler) TranspileContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	return t.limitOutput(ctx, out, err)
+}
+
+// evalDocument evaluates the program for an encoder and checks the result
+// against the schema set with SetSchema, if any
+func (t *Transpiler) evalDocument(ctx context.Context) (map[string]interface{}, error) {
+	root, err := t.evalProgram(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkContext(ctx, nil); err != nil {
+		return nil, err
+	}
+	if t.schema != nil {
+		if err := t.validateSchema(ctx, root); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// evalProgram evaluates every statement and returns the output document.
+// All encoders (JSON, YAML, TOML, TypeScript) serialise the result of this pass.
+func (t *Transpiler) evalProgram(ctx context.Context) (map[string]interface{}, error) {
 	root := make(map[string]interface{})
+	if err := t.compileTypes(); err != nil {
+		return nil, err
+	}
 
 	for _, stmt := range t.program.Statements {
 		switch s := stmt.(type) {
 		case *ast.VariableDeclaration:
 			// Variable declarations are stored in symbol table but not added to output
-			val, err := t.evalExpression(s.Value, nil)
+			val, err := t.evalExpression(ctx, s.Value, nil)
 			if err != nil {
 				return nil, err
 			}
 			t.symbolTable[s.Name.Value] = val
 		case *ast.AssignmentStatement:
 			key := s.Name.Value
-			val, err := t.evalExpression(s.Value, nil)
+			val, err := t.evalExpression(ctx, s.Value, nil)
 			if err != nil {
 				return nil, err
 			}
+			if s.Type != nil {
+				if err := t.checkAnnotation(ctx, s, val); err != nil {
+					return nil, err
+				}
+			}
 			// Store in symbol table so it can be referenced by other expressions
 			t.symbolTable[key] = val
 			// Also add to output
 			root[key] = val
 		case *ast.IncludeStatement:
-			// Read included file and merge its output into root (do not overwrite existing keys)
-			includePath := s.Path.Value
-
-			// Resolve path relative to the current Transpiler baseDir when not absolute
-			var includeAbs string
-			if filepath.IsAbs(includePath) {
-				includeAbs = filepath.Clean(includePath)
-			} else {
-				includeAbs = filepath.Clean(filepath.Join(t.baseDir, includePath))
+			if err := t.evalInclude(ctx, s, root); err != nil {
+				return nil, err
 			}
-
-			// Detect cyclic include
-			if t.inProgress[includeAbs] {
-				return nil, t.errfNodeMsg(s, ie.CyclicInclude(includeAbs))
+		case *ast.AssertStatement:
+			if err := t.evalAssert(ctx, s, nil); err != nil {
+				return nil, err
 			}
+		}
+	}
 
-			// If cached, use cached result
-			if cached, ok := t.includeCache[includeAbs]; ok {
-				for k, v := range cached {
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				}
-				break
-			}
+	return root, nil
+}
 
-			// Mark as in-progress
-			t.inProgress[includeAbs] = true
+// evalInclude evaluates the included file(s) and merges their output into root
+// according to mergeMode
+func (t *Transpiler) evalInclude(ctx context.Context, s *ast.IncludeStatement, root map[string]interface{}) error {
+	paths, err := t.resolveIncludePaths(s)
+	if err != nil {
+		return err
+	}
+
+	// keyedFrom remembers which file produced each key so clashes can be reported
+	keyedFrom := make(map[string]string)
+	for _, includeAbs := range paths {
+		// Detect cyclic include
+		if t.inProgress[includeAbs] {
+			return t.errfNodeMsg(s, ie.CyclicInclude(includeAbs))
+		}
 
-			data, err := os.ReadFile(includeAbs)
+		incRoot, ok := t.includeCache[includeAbs]
+		if !ok {
+			incRoot, err = t.evalIncludedFile(ctx, s, includeAbs)
 			if err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "could not read include file %q — gremlin can't find it: %v", s.Path.Value, err)
+				return err
 			}
+		}
 
-			l := lexer.New(string(data))
-			l.SetSourceFile(includeAbs)
-			p := parser.New(l)
-			prog := p.ParseProgram()
-			if len(p.Errors()) > 0 {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "parser errors in included file %q — wizard got confused: %v", s.Path.Value, p.Errors())
+		// target is where the output merges: root, or the object at the mount path
+		target := root
+		mountAt := s.Mount
+		if s.Keyed {
+			key := strings.TrimSuffix(filepath.Base(includeAbs), filepath.Ext(includeAbs))
+			if prev, dup := keyedFrom[key]; dup {
+				return t.errfNodeMsg(s, ie.DuplicateIncludeKey(key, prev, includeAbs))
 			}
-
-			// Create a transpiler for the included program, setting its baseDir to the included file's dir
-			incBase := filepath.Dir(includeAbs)
-			incT := New(prog, incBase, t.mergeMode, includeAbs)
-			// share cache and inProgress maps so nested includes use the same state
-			incT.includeCache = t.includeCache
-			incT.inProgress = t.inProgress
-
-			incJSON, err := incT.Transpile()
+			keyedFrom[key] = includeAbs
+			incRoot = map[string]interface{}{key: incRoot}
+		} else if len(mountAt) > 0 {
+			// Mount the whole document at the last path element
+			incRoot = map[string]interface{}{mountAt[len(mountAt)-1]: incRoot}
+			mountAt = mountAt[:len(mountAt)-1]
+		}
+		if len(mountAt) > 0 {
+			target, err = t.mountTarget(s, root, mountAt)
 			if err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "transpile error in included file %q: %v", s.Path.Value, err)
+				return err
 			}
+		}
 
-			var incRoot map[string]interface{}
-			if err := json.Unmarshal(incJSON, &incRoot); err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "invalid json from include %q: %v", s.Path.Value, err)
-			}
+		if err := t.mergeInclude(s, includeAbs, target, incRoot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			// Cache result
-			t.includeCache[includeAbs] = incRoot
-			// Done processing
-			t.inProgress[includeAbs] = false
-
-			// Merge according to mergeMode
-			for k, v := range incRoot {
-				switch t.mergeMode {
-				case "keep":
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				case "overwrite":
-					root[k] = v
-				case "error":
-					if _, exists := root[k]; exists {
-						return nil, t.errfNode(s, "include merge conflict for key %q from %s", k, includeAbs)
-					}
-					root[k] = v
-				default:
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				}
+// mountTarget walks root along path, creating objects where keys are missing,
+// and returns the object at the end. Existing objects on the way are copied
+// before being written to, since they may be shared with the include cache.
+func (t *Transpiler) mountTarget(s *ast.IncludeStatement, root map[string]interface{}, path []string) (map[string]interface{}, error) {
+	cur := root
+	for i, key := range path {
+		var next map[string]interface{}
+		switch existing := cur[key].(type) {
+		case nil:
+			next = make(map[string]interface{})
+		case map[string]interface{}:
+			next = make(map[string]interface{}, len(existing))
+			for k, v := range existing {
+				next[k] = v
 			}
+		default:
+			return nil, t.errfNodeMsg(s, ie.MountNotObject(strings.Join(path[:i+1], "."), existing))
 		}
+		cur[key] = next
+		cur = next
 	}
-
-	return json.MarshalIndent(root, "", "  ")
+	return cur, nil
 }
 
-func (t *Transpiler) errf(format string, args ...interface{}) error {
-	prefix := "Transpile gremlin:"
-	if t != nil && t.sourceFile != "" {
-		ctx := ie.FormatContext(t.sourceFile, 1, 1)
-		return fmt.Errorf("%s %s — %s", prefix, ctx, fmt.Sprintf(format, args...))
+// resolveIncludePaths turns an include path into absolute file paths. Glob
+// patterns and directories (which include every .jsson file inside) expand to
+// their matches in sorted order; the including file itself is never matched.
+func (t *Transpiler) resolveIncludePaths(s *ast.IncludeStatement) ([]string, error) {
+	includePath := s.Path.Value
+
+	// Resolve path relative to the current Transpiler baseDir when not absolute
+	includeAbs, err := t.resolver.Resolve(t.baseDir, includePath)
+	if err != nil {
+		if isSandboxError(err) {
+			return nil, t.errfNodeMsg(s, ie.SandboxViolation(includePath, err))
+		}
+		return nil, t.errfNodeMsg(s, ie.IncludeNotResolved(includePath, err))
 	}
-	return fmt.Errorf("%s — %s", prefix, fmt.Sprintf(format, args...))
+
+	pattern := includePath
+	if info, err := t.resolver.Stat(includeAbs); err == nil && info.IsDir() {
+		pattern = strings.TrimRight(includePath, "/") + "/*.jsson"
+	} else if !strings.ContainsAny(includePath, "*?[") {
+		return []string{includeAbs}, nil
+	}
+
+	matches, err := t.resolver.Glob(t.baseDir, pattern)
+	if err != nil {
+		if isSandboxError(err) {
+			return nil, t.errfNodeMsg(s, ie.SandboxViolation(includePath, err))
+		}
+		return nil, t.errfNodeMsg(s, ie.BadIncludePattern(includePath, err))
+	}
+	sort.Strings(matches)
+
+	paths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if info, err := t.resolver.Stat(m); err != nil || info.IsDir() {
+			continue
+		}
+		if t.sourceFile != "" && m == t.sourceFile {
+			continue
+		}
+		paths = append(paths, m)
+	}
+	if len(paths) == 0 {
+		return nil, t.errfNodeMsg(s, ie.NoIncludeMatches(includePath))
+	}
+	return paths, nil
 }
 
-func (t *Transpiler) errfNode(node ast.Node, format string, args ...interface{}) error {
-	prefix := "Transpile gremlin:"
-	var line, col int
-	if node != nil {
-		switch n := node.(type) {
-		case *ast.AssignmentStatement:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.IncludeStatement:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.IntegerLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.FloatLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.StringLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.Identifier:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ObjectLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ArrayLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.RangeExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ArrayTemplate:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.MapClause:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.BinaryExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.MemberExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.MapExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ConditionalExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.InterpolatedString:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.BooleanLiteral:
-			line, col = n.Token.Line, n.Token.Column
+// mergeInclude merges an included file's output into root according to mergeMode
+func (t *Transpiler) mergeInclude(s *ast.IncludeStatement, includeAbs string, root, incRoot map[string]interface{}) error {
+	for k, v := range incRoot {
+		switch t.mergeMode {
+		case "keep":
+			if _, exists := root[k]; !exists {
+				root[k] = v
+			}
+		case "overwrite":
+			root[k] = v
+		case "error":
+			if _, exists := root[k]; exists {
+				return t.errfNode(s, "include merge conflict for key %q from %s", k, includeAbs)
+			}
+			root[k] = v
 		default:
-			line, col = 0, 0
+			if _, exists := root[k]; !exists {
+				root[k] = v
+			}
 		}
 	}
+	return nil
+}
+
+// evalIncludedFile reads, parses and evaluates an included file, caching its output
+func (t *Transpiler) evalIncludedFile(ctx context.Context, s *ast.IncludeStatement, includeAbs string) (map[string]interface{}, error) {
+	if t.maxIncludeDepth > 0 && t.includeDepth >= t.maxIncludeDepth {
+		return nil, t.errfNodeMsg(s, ie.IncludeTooDeep(includeAbs, t.maxIncludeDepth))
+	}
+	if err := t.countFileRead(s); err != nil {
+		return nil, err
+	}
 
+	// Mark as in-progress
+	t.inProgress[includeAbs] = true
+	defer func() { t.inProgress[includeAbs] = false }()
+
+	data, err := t.resolver.ReadFile(includeAbs)
+	if isSandboxError(err) {
+		return nil, t.errfNodeMsg(s, ie.SandboxViolation(s.Path.Value, err))
+	}
+	if err != nil {
+		return nil, t.errfNode(s, "could not read include file %q — gremlin can't find it: %v", s.Path.Value, err)
+	}
+
+	l := lexer.New(string(data))
+	l.SetSourceFile(includeAbs)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, t.errfNode(s, "parser errors in included file %q — wizard got confused: %v", s.Path.Value, p.Errors())
+	}
+
+	// Create a transpiler for the included program, setting its baseDir to the included file's dir
+	incT := t.child(prog, t.resolver.Dir(includeAbs), includeAbs)
+
+	incRoot, err := incT.evalProgram(ctx)
+	if err != nil {
+		return nil, t.errfNodeWrap(s, fmt.Sprintf("transpile error in included file %q", s.Path.Value), err)
+	}
+
+	// Cache result
+	t.includeCache[includeAbs] = incRoot
+	t.includeDocs[includeAbs] = incT.collectDocs()
+	return incRoot, nil
+}
+
+// countFileRead enforces the include file limit; load() data files count too
+func (t *Transpiler) countFileRead(node ast.Node) error {
+	*t.filesRead++
+	if t.maxIncludeFiles > 0 && *t.filesRead > t.maxIncludeFiles {
+		return t.errfNodeMsg(node, ie.TooManyIncludeFiles(t.maxIncludeFiles))
+	}
+	return nil
+}
+
+// child creates a transpiler for an included program that shares this one's
+// caches, cycle detection state, resolver and options
+func (t *Transpiler) child(prog *ast.Program, baseDir, sourceFile string) *Transpiler {
+	c := New(prog, baseDir, t.mergeMode, sourceFile)
+	c.includeCache = t.includeCache
+	c.includeDocs = t.includeDocs
+	c.inProgress = t.inProgress
+	c.loadCache = t.loadCache
+	c.resolver = t.resolver
+	c.maxIncludeDepth = t.maxIncludeDepth
+	c.maxIncludeFiles = t.maxIncludeFiles
+	c.includeDepth = t.includeDepth + 1
+	c.filesRead = t.filesRead
+	c.decimalMode = t.decimalMode
+	c.budget = t.budget
+	c.depth = t.depth
+	c.runCtx = t.runCtx
+	c.parallelism = t.parallelism
+	return c
+}
+
+func (t *Transpiler) errf(format string, args ...interface{}) error {
+	prefix := "Transpile gremlin:"
 	if t != nil && t.sourceFile != "" {
-		if line > 0 && col > 0 {
-			ctx := ie.FormatContext(t.sourceFile, line, col)
-			return fmt.Errorf("%s %s — %s", prefix, ctx, fmt.Sprintf(format, args...))
-		}
-		// fallback to file-only context
 		ctx := ie.FormatContext(t.sourceFile, 1, 1)
 		return fmt.Errorf("%s %s — %s", prefix, ctx, fmt.Sprintf(format, args...))
 	}
 	return fmt.Errorf("%s — %s", prefix, fmt.Sprintf(format, args...))
 }
 
+func (t *Transpiler) errfNode(node ast.Node, format string, args ...interface{}) error {
+	return t.errfNodeMsg(node, fmt.Sprintf(format, args...))
+}
+
 // errfNodeMsg formats an already-formatted error message with node context
 func (t *Transpiler) errfNodeMsg(node ast.Node, msg string) error {
-	prefix := "Transpile gremlin:"
-	var line, col int
-	if node != nil {
-		switch n := node.(type) {
-		case *ast.AssignmentStatement:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.IncludeStatement:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.IntegerLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.FloatLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.StringLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.Identifier:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ObjectLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ArrayLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.RangeExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ArrayTemplate:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.MapClause:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.BinaryExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.MemberExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.MapExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.ConditionalExpression:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.InterpolatedString:
-			line, col = n.Token.Line, n.Token.Column
-		case *ast.BooleanLiteral:
-			line, col = n.Token.Line, n.Token.Column
-		default:
-			line, col = 0, 0
-		}
+	return t.errfNodeWrap(node, msg, nil)
+}
+
+// errfNodeWrap is errfNodeMsg for a failure caused by err. The result wraps err,
+// so callers can still match it with errors.Is (e.g. context.Canceled).
+func (t *Transpiler) errfNodeWrap(node ast.Node, msg string, err error) error {
+	line, col := nodePosition(node)
+	head := t.errHead(line, col, msg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", head, err)
 	}
+	return errors.New(head)
+}
 
-	if t != nil && t.sourceFile != "" {
-		if line > 0 && col > 0 {
-			ctx := ie.FormatContext(t.sourceFile, line, col)
-			return fmt.Errorf("%s %s — %s", prefix, ctx, msg)
-		}
+// errHead prefixes msg with the source context of line and col
+func (t *Transpiler) errHead(line, col int, msg string) string {
+	prefix := "Transpile gremlin:"
+	if t == nil || t.sourceFile == "" {
+		return fmt.Sprintf("%s — %s", prefix, msg)
+	}
+	if line <= 0 || col <= 0 {
+		// fallback to file-only context
+		line, col = 1, 1
+	}
+	return fmt.Sprintf("%s %s — %s", prefix, ie.FormatContext(t.sourceFile, line, col), msg)
+}
 
-		ctx := ie.FormatContext(t.sourceFile, 1, 1)
-		return fmt.Errorf("%s %s — %s", prefix, ctx, msg)
+// nodePosition returns the source line and column of the token that starts node
+func nodePosition(node ast.Node) (int, int) {
+	switch n := node.(type) {
+	case *ast.AssignmentStatement:
+		return n.Token.Line, n.Token.Column
+	case *ast.IncludeStatement:
+		return n.Token.Line, n.Token.Column
+	case *ast.IntegerLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.FloatLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.StringLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.NullLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.Identifier:
+		return n.Token.Line, n.Token.Column
+	case *ast.ObjectLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.ArrayLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.RangeExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.ArrayTemplate:
+		return n.Token.Line, n.Token.Column
+	case *ast.MapClause:
+		return n.Token.Line, n.Token.Column
+	case *ast.BinaryExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.MemberExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.PrefixExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.CallExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.MapExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.ConditionalExpression:
+		return n.Token.Line, n.Token.Column
+	case *ast.InterpolatedString:
+		return n.Token.Line, n.Token.Column
+	case *ast.BooleanLiteral:
+		return n.Token.Line, n.Token.Column
+	case *ast.NamedType:
+		return n.Token.Line, n.Token.Column
+	case *ast.ObjectType:
+		return n.Token.Line, n.Token.Column
+	case *ast.AssertStatement:
+		return n.Token.Line, n.Token.Column
 	}
-	return fmt.Errorf("%s — %s", prefix, msg)
+	return 0, 0
 }
 
 // errMsg formats an already-formatted error message with context
@@ -303,56 +496,46 @@ func (t *Transpiler) errMsg(msg string) error {
 	return fmt.Errorf("%s — %s", prefix, msg)
 }
 
-func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interface{}) (interface{}, error) {
+func (t *Transpiler) evalExpression(ctx context.Context, expr ast.Expression, scope *Scope) (interface{}, error) {
+	if t.budget != nil {
+		if err := t.enterExpression(expr); err != nil {
+			return nil, err
+		}
+		defer t.leaveExpression()
+	}
 	switch e := expr.(type) {
 	case *ast.IntegerLiteral:
 		return e.Value, nil
 	case *ast.FloatLiteral:
+		if t.decimalMode {
+			if d, ok := NewDecimal(e.Token.Literal); ok {
+				return d, nil
+			}
+		}
 		return e.Value, nil
 	case *ast.BooleanLiteral:
 		return e.Value, nil
 	case *ast.StringLiteral:
 		return e.Value, nil
+	case *ast.NullLiteral:
+		return nil, nil
 	case *ast.MapExpression:
 		// Evaluate the array to be mapped
-		leftVal, err := t.evalExpression(e.Left, ctx)
+		leftVal, err := t.evalExpression(ctx, e.Left, scope)
 		if err != nil {
 			return nil, err
 		}
 
-		// Ensure it's an array (slice). Accept RangeResult (wraps generated slice) as well.
-		var items []interface{}
-		switch v := leftVal.(type) {
-		case []interface{}:
-			items = v
-		case RangeResult:
-			items = v.Values
-		default:
+		// Ensure it's an array or a range; ranges are read by index, never expanded
+		items, ok := asSequence(leftVal)
+		if !ok {
 			return nil, t.errfNode(e, "map target is not an array, it's a %T — gremlin is confused", leftVal)
 		}
 
-		var result []interface{}
-
-		// Iterate and map
-		for _, item := range items {
-			// Create a new scope for the iteration
-			// We copy the current context to allow access to outer variables
-			newCtx := make(map[string]interface{})
-			if ctx != nil {
-				for k, v := range ctx {
-					newCtx[k] = v
-				}
-			}
-			// Bind the iterator variable
-			newCtx[e.Iterator.Value] = item
-			// Evaluate the body
-			mappedVal, err := t.evalExpression(e.Body, newCtx)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, mappedVal)
+		if err := t.countElements(e, items.Len()); err != nil {
+			return nil, err
 		}
-		return result, nil
+		return t.mapItems(ctx, e, items, e.Iterator.Value, e.Body, scope)
 
 	case *ast.InterpolatedString:
 		// Evaluate interpolations and build the final string
@@ -364,10 +547,7 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 			case ast.Expression:
 
 				if ident, ok := p.(*ast.Identifier); ok {
-					found := false
-					if ctx != nil {
-						_, found = ctx[ident.Value]
-					}
+					_, found := scope.Get(ident.Value)
 
 					if !found {
 
@@ -385,20 +565,21 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 					}
 				}
 
-				val, err := t.evalExpression(p, ctx)
+				val, err := t.evalExpression(ctx, p, scope)
 				if err != nil {
 					return nil, err
 				}
-				result.WriteString(fmt.Sprintf("%v", val))
+				result.WriteString(formatValue(val))
+				if err := t.checkStringSize(e, result.String()); err != nil {
+					return nil, err
+				}
 			}
 		}
 		return result.String(), nil
 	case *ast.Identifier:
 		// Variable lookup: check context (local) first, then symbol table (global)
-		if ctx != nil {
-			if val, ok := ctx[e.Value]; ok {
-				return val, nil
-			}
+		if val, ok := scope.Get(e.Value); ok {
+			return val, nil
 		}
 		if val, ok := t.symbolTable[e.Value]; ok {
 			return val, nil
@@ -407,19 +588,15 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 	case *ast.ObjectLiteral:
 		obj := make(map[string]interface{})
 
-		localCtx := make(map[string]interface{})
-		if ctx != nil {
-			for k, v := range ctx {
-				localCtx[k] = v
-			}
-		}
-
+		// Local declarations bind on top of the outer scope, visible to later
+		// declarations and to the properties
+		local := scope
 		for _, decl := range e.Declarations {
-			val, err := t.evalExpression(decl.Value, localCtx)
+			val, err := t.evalExpression(ctx, decl.Value, local)
 			if err != nil {
 				return nil, err
 			}
-			localCtx[decl.Name.Value] = val
+			local = local.Bind(decl.Name.Value, val)
 		}
 
 		// Evaluate properties using local context
@@ -428,43 +605,71 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 			if valExpr == nil {
 				continue
 			}
-			val, err := t.evalExpression(valExpr, localCtx)
+			val, err := t.evalExpression(ctx, valExpr, local)
 			if err != nil {
 				return nil, err
 			}
 			obj[key] = val
 		}
+
+		// Includes inside the body merge into this object, after its own properties
+		for _, inc := range e.Includes {
+			if err := t.evalInclude(ctx, inc, obj); err != nil {
+				return nil, err
+			}
+		}
+
+		// Assertions see the finished object's keys on top of its locals
+		if len(e.Asserts) > 0 {
+			checked := local
+			for key, val := range obj {
+				checked = checked.Bind(key, val)
+			}
+			for _, a := range e.Asserts {
+				if err := t.evalAssert(ctx, a, checked); err != nil {
+					return nil, err
+				}
+			}
+		}
 		return obj, nil
 	case *ast.ArrayLiteral:
 		arr := make([]interface{}, 0, len(e.Elements))
 		for _, el := range e.Elements {
-			val, err := t.evalExpression(el, ctx)
+			val, err := t.evalExpression(ctx, el, scope)
 			if err != nil {
 				return nil, err
 			}
-			// If the element is a RangeResult, flatten it
-			if rr, ok := val.(RangeResult); ok {
-				arr = append(arr, rr.Values...)
-			} else {
+			rr, ok := val.(RangeResult)
+			if !ok {
 				arr = append(arr, val)
+				continue
+			}
+			// A range inside an array literal is flattened into it
+			for i := 0; i < rr.Len(); i++ {
+				if i%cancelCheckEvery == 0 {
+					if err := t.checkContext(ctx, e); err != nil {
+						return nil, err
+					}
+				}
+				arr = append(arr, rr.At(i))
 			}
 		}
 		return arr, nil
 
 	case *ast.RangeExpression:
 		// Evaluate start, end and optional step
-		startV, err := t.evalExpression(e.Start, ctx)
+		startV, err := t.evalExpression(ctx, e.Start, scope)
 		if err != nil {
 			return nil, err
 		}
-		endV, err := t.evalExpression(e.End, ctx)
+		endV, err := t.evalExpression(ctx, e.End, scope)
 		if err != nil {
 			return nil, err
 		}
 
 		var stepV interface{}
 		if e.Step != nil {
-			stepV, err = t.evalExpression(e.Step, ctx)
+			stepV, err = t.evalExpression(ctx, e.Step, scope)
 			if err != nil {
 				return nil, err
 			}
@@ -474,7 +679,7 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 		if startStr, ok1 := startV.(string); ok1 {
 			if endStr, ok2 := endV.(string); ok2 {
 				// String Range: find numeric suffix and increment it
-				return t.evalStringRange(startStr, endStr, stepV, e)
+				return t.evalStringRange(ctx, startStr, endStr, stepV, e)
 			}
 		}
 
@@ -502,17 +707,16 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 			return nil, t.errfNodeMsg(e, ie.StepCannotBeZero())
 		}
 
-		res := make([]interface{}, 0)
-		if step > 0 {
-			for i := sInt; i <= eInt; i += step {
-				res = append(res, i)
-			}
-		} else {
-			for i := sInt; i >= eInt; i += step {
-				res = append(res, i)
-			}
+		// The range stays lazy; only its length counts against the limits here
+		rr, ok := newRange(sInt, eInt, step)
+		if !ok {
+			return nil, t.errfNodeMsg(e, ie.RangeTooLarge(sInt, eInt, step))
+		}
+		if err := t.countElements(e, rr.Len()); err != nil {
+			return nil, err
 		}
-		return RangeResult{Values: res}, nil
+		rr.ctx, rr.budget = t.runCtx, t.budget
+		return rr, nil
 	case *ast.ArrayTemplate:
 		result := make([]interface{}, 0, len(e.Rows))
 		keys := e.Template.Keys
@@ -523,19 +727,40 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 			isImplicitTemplate = true
 		}
 
+		// appendRow adds a row to the result; the map clause, if present, runs over
+		// all rows once they're built
+		appendRow := func(itemValue interface{}) error {
+			if err := t.addElement(ctx, e, len(result)); err != nil {
+				return err
+			}
+			result = append(result, itemValue)
+			return nil
+		}
+
 		for _, row := range e.Rows {
 			// First, evaluate all expressions in the row
 			evaluatedRow := make([]interface{}, len(row))
 			for i, expr := range row {
-				val, err := t.evalExpression(expr, ctx)
+				val, err := t.evalExpression(ctx, expr, scope)
 				if err != nil {
 					return nil, err
 				}
-				if rr, ok := val.(RangeResult); ok {
-					evaluatedRow[i] = rr.Values
-				} else {
-					evaluatedRow[i] = val
+				evaluatedRow[i] = val
+			}
+
+			// A row that is just a load() of records (e.g. load("users.csv")) expands
+			// into one row per record, picking the template keys by name
+			if objects, ok := objectRows(row, evaluatedRow); ok && !isImplicitTemplate {
+				for _, obj := range objects {
+					rowObj := make(map[string]interface{}, len(keys))
+					for _, key := range keys {
+						rowObj[key] = obj[key]
+					}
+					if err := appendRow(rowObj); err != nil {
+						return nil, err
+					}
 				}
+				continue
 			}
 
 			// Check if we have ranges that need zipping
@@ -544,18 +769,18 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 			minArrayLength := -1
 
 			for _, val := range evaluatedRow {
-				if arr, ok := val.([]interface{}); ok {
+				if seq, ok := asSequence(val); ok {
 					isObjectArray := false
-					if len(arr) > 0 {
-						if _, isMap := arr[0].(map[string]interface{}); isMap {
+					if seq.Len() > 0 {
+						if _, isMap := seq.Index(0).(map[string]interface{}); isMap {
 							isObjectArray = true
 						}
 					}
 
 					if !isObjectArray {
 						hasArrays = true
-						if minArrayLength == -1 || len(arr) < minArrayLength {
-							minArrayLength = len(arr)
+						if minArrayLength == -1 || seq.Len() < minArrayLength {
+							minArrayLength = seq.Len()
 						}
 					}
 				}
@@ -569,8 +794,8 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 
 					if isImplicitTemplate {
 						// For implicit templates, pass the value directly
-						if arr, ok := evaluatedRow[0].([]interface{}); ok {
-							itemValue = arr[idx]
+						if seq, ok := asSequence(evaluatedRow[0]); ok {
+							itemValue = seq.Index(idx)
 						} else {
 							itemValue = evaluatedRow[0]
 						}
@@ -583,9 +808,9 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 							}
 							key := keys[i]
 
-							// If it's an array, take the element at idx
-							if arr, ok := val.([]interface{}); ok {
-								rowObj[key] = arr[idx]
+							// If it's an array or range, take the element at idx
+							if seq, ok := asSequence(val); ok {
+								rowObj[key] = seq.Index(idx)
 							} else {
 								// If it's not an array, use the same value for all rows
 								rowObj[key] = val
@@ -594,21 +819,8 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 						itemValue = rowObj
 					}
 
-					// Apply Map Clause if present
-					if e.Map != nil {
-						mapCtx := make(map[string]interface{})
-						for k, v := range ctx {
-							mapCtx[k] = v
-						}
-						mapCtx[e.Map.Param.Value] = itemValue
-
-						mappedVal, err := t.evalExpression(e.Map.Body, mapCtx)
-						if err != nil {
-							return nil, err
-						}
-						result = append(result, mappedVal)
-					} else {
-						result = append(result, itemValue)
+					if err := appendRow(itemValue); err != nil {
+						return nil, err
 					}
 				}
 			} else {
@@ -631,38 +843,44 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 					itemValue = rowObj
 				}
 
-				// Apply Map Clause if present
-				if e.Map != nil {
-					mapCtx := make(map[string]interface{})
-					for k, v := range ctx {
-						mapCtx[k] = v
-					}
-					mapCtx[e.Map.Param.Value] = itemValue
-
-					mappedVal, err := t.evalExpression(e.Map.Body, mapCtx)
-					if err != nil {
-						return nil, err
-					}
-					result = append(result, mappedVal)
-				} else {
-					result = append(result, itemValue)
+				if err := appendRow(itemValue); err != nil {
+					return nil, err
 				}
 			}
 		}
+		if e.Map != nil {
+			return t.mapItems(ctx, e.Map, arraySeq(result), e.Map.Param.Value, e.Map.Body, scope)
+		}
 		return result, nil
 	case *ast.BinaryExpression:
-		left, err := t.evalExpression(e.Left, ctx)
+		left, err := t.evalExpression(ctx, e.Left, scope)
 		if err != nil {
 			return nil, err
 		}
-		right, err := t.evalExpression(e.Right, ctx)
+		// Null-coalescing only evaluates the right side when the left is null
+		if e.Operator == "??" {
+			if left != nil {
+				return left, nil
+			}
+			return t.evalExpression(ctx, e.Right, scope)
+		}
+		right, err := t.evalExpression(ctx, e.Right, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.evalBinary(e, left, e.Operator, right)
+	case *ast.CallExpression:
+		return t.evalCall(ctx, e, scope)
+	case *ast.PrefixExpression:
+		right, err := t.evalExpression(ctx, e.Right, scope)
 		if err != nil {
 			return nil, err
 		}
 
-		return t.evalBinary(left, e.Operator, right)
+		return t.evalPrefix(e, right)
 	case *ast.ConditionalExpression:
-		condition, err := t.evalExpression(e.Condition, ctx)
+		condition, err := t.evalExpression(ctx, e.Condition, scope)
 		if err != nil {
 			return nil, err
 		}
@@ -671,106 +889,105 @@ func (t *Transpiler) evalExpression(expr ast.Expression, ctx map[string]interfac
 		isTruthy := t.isTruthy(condition)
 
 		if isTruthy {
-			return t.evalExpression(e.Consequence, ctx)
+			return t.evalExpression(ctx, e.Consequence, scope)
 		} else {
-			return t.evalExpression(e.Alternative, ctx)
+			return t.evalExpression(ctx, e.Alternative, scope)
 		}
 	case *ast.MemberExpression:
-		leftVal, err := t.evalExpression(e.Left, ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		// Handle map access
-		if obj, ok := leftVal.(map[string]interface{}); ok {
-			if val, ok := obj[e.Property.Value]; ok {
-				return val, nil
-			}
-			// Debug info suppressed
-			return nil, t.errfNode(e, "property %q not found — gremlin searched everywhere", e.Property.Value)
-		}
-		return nil, t.errfNodeMsg(e, ie.NotAnObject())
+		val, _, err := t.evalMember(ctx, e, scope)
+		return val, err
 	default:
 		return nil, t.errfNode(expr, "unknown expression type: %T", expr)
 	}
 }
 
-func (t *Transpiler) evalBinary(left interface{}, op string, right interface{}) (interface{}, error) {
+func (t *Transpiler) evalBinary(node ast.Node, left interface{}, op string, right interface{}) (interface{}, error) {
 	// Prevent applying numeric/string operators directly to a RangeResult
 	if _, ok := left.(RangeResult); ok {
-		return nil, t.errMsg(fmt.Sprintf("cannot apply operator %q to a range — expand it or use in an array context", op))
+		return nil, t.errfNodeMsg(node, fmt.Sprintf("cannot apply operator %q to a range — expand it or use in an array context", op))
 	}
 	if _, ok := right.(RangeResult); ok {
-		return nil, t.errMsg(fmt.Sprintf("cannot apply operator %q to a range — expand it or use in an array context", op))
+		return nil, t.errfNodeMsg(node, fmt.Sprintf("cannot apply operator %q to a range — expand it or use in an array context", op))
 	}
 	switch op {
-	case "+":
+	case "+", "-", "*", "/":
 		// String concatenation
-		if lStr, ok := left.(string); ok {
-			return lStr + fmt.Sprintf("%v", right), nil
-		}
-		if rStr, ok := right.(string); ok {
-			return fmt.Sprintf("%v", left) + rStr, nil
-		}
-		// Numeric addition
-		lFloat, lIsFloat := toFloat(left)
-		rFloat, rIsFloat := toFloat(right)
-		if lIsFloat || rIsFloat {
-			return lFloat + rFloat, nil
-		}
-		if lInt, ok := left.(int64); ok {
-			if rInt, ok := right.(int64); ok {
-				return lInt + rInt, nil
+		if op == "+" {
+			_, lIsStr := left.(string)
+			_, rIsStr := right.(string)
+			if lIsStr || rIsStr {
+				s := formatValue(left) + formatValue(right)
+				if err := t.checkStringSize(node, s); err != nil {
+					return nil, err
+				}
+				return s, nil
 			}
 		}
-	case "-":
-		// Numeric subtraction
-		lFloat, lIsFloat := toFloat(left)
-		rFloat, rIsFloat := toFloat(right)
-		if lIsFloat || rIsFloat {
-			return lFloat - rFloat, nil
-		}
-		if lInt, ok := left.(int64); ok {
-			if rInt, ok := right.(int64); ok {
-				return lInt - rInt, nil
+		// Exact decimal arithmetic when either side is a decimal
+		if isDecimal(left) || isDecimal(right) {
+			if res, ok, err := t.evalDecimal(node, left, op, right); ok || err != nil {
+				return res, err
 			}
+			break
 		}
-	case "*":
-		// Numeric multiplication
 		lFloat, lIsFloat := toFloat(left)
 		rFloat, rIsFloat := toFloat(right)
 		if lIsFloat || rIsFloat {
-			return lFloat * rFloat, nil
-		}
-		if lInt, ok := left.(int64); ok {
-			if rInt, ok := right.(int64); ok {
-				return lInt * rInt, nil
+			if _, ok := toInt64(left); !ok && !lIsFloat {
+				break
+			}
+			if _, ok := toInt64(right); !ok && !rIsFloat {
+				break
+			}
+			switch op {
+			case "+":
+				return lFloat + rFloat, nil
+			case "-":
+				return lFloat - rFloat, nil
+			case "*":
+				return lFloat * rFloat, nil
 			}
-		}
-	case "/":
-		// Numeric division
-		lFloat, lIsFloat := toFloat(left)
-		rFloat, rIsFloat := toFloat(right)
-		if lIsFloat || rIsFloat {
 			if rFloat == 0 {
-				return nil, t.errMsg(ie.DivisionByZero())
+				return nil, t.errfNodeMsg(node, ie.DivisionByZero())
 			}
 			return lFloat / rFloat, nil
 		}
-		if lInt, ok := left.(int64); ok {
-			if rInt, ok := right.(int64); ok {
-				if rInt == 0 {
-					return nil, t.errMsg(ie.DivisionByZero())
-				}
-				return lInt / rInt, nil
+		lInt, okL := left.(int64)
+		rInt, okR := right.(int64)
+		if !okL || !okR {
+			break
+		}
+		var res int64
+		ok := true
+		switch op {
+		case "+":
+			res, ok = addInt64(lInt, rInt)
+		case "-":
+			res, ok = subInt64(lInt, rInt)
+		case "*":
+			res, ok = mulInt64(lInt, rInt)
+		case "/":
+			if rInt == 0 {
+				return nil, t.errfNodeMsg(node, ie.DivisionByZero())
 			}
+			// MinInt64 / -1 is the one quotient that does not fit
+			ok = !(lInt == math.MinInt64 && rInt == -1)
+			res = lInt / rInt
+		}
+		if !ok {
+			return nil, t.errfNodeMsg(node, ie.IntegerOverflow(fmt.Sprintf("%d %s %d", lInt, op, rInt)))
 		}
+		return res, nil
 	case "%":
 		// Int modulo: accept int/int64 combinations
 		if lInt, okL := toInt64(left); okL {
 			if rInt, okR := toInt64(right); okR {
 				if rInt == 0 {
-					return nil, t.errMsg(ie.ModuloByZero())
+					return nil, t.errfNodeMsg(node, ie.ModuloByZero())
+				}
+				if rInt == -1 {
+					// Avoids the MinInt64 % -1 trap; the remainder is always 0
+					return int64(0), nil
 				}
 				return lInt % rInt, nil
 			}
@@ -781,21 +998,21 @@ func (t *Transpiler) evalBinary(left interface{}, op string, right interface{})
 	case "!=":
 		return !t.compareEqual(left, right), nil
 	case "<":
-		return t.compareLess(left, right)
+		return t.compareLess(node, left, right)
 	case ">":
-		return t.compareLess(right, left)
+		return t.compareLess(node, right, left)
 	case "<=":
 		eq := t.compareEqual(left, right)
 		if eq {
 			return true, nil
 		}
-		return t.compareLess(left, right)
+		return t.compareLess(node, left, right)
 	case ">=":
 		eq := t.compareEqual(left, right)
 		if eq {
 			return true, nil
 		}
-		return t.compareLess(right, left)
+		return t.compareLess(node, right, left)
 	case "&&":
 		// Logical AND: both operands must be truthy
 		return t.isTruthy(left) && t.isTruthy(right), nil
@@ -803,7 +1020,185 @@ func (t *Transpiler) evalBinary(left interface{}, op string, right interface{})
 		// Logical OR: at least one operand must be truthy
 		return t.isTruthy(left) || t.isTruthy(right), nil
 	}
-	return nil, t.errMsg(ie.UnsupportedBinaryOp(left, op, right))
+	return nil, t.errfNodeMsg(node, ie.UnsupportedBinaryOp(left, op, right))
+}
+
+// objectRows reports whether a template row is a single load() call that gave
+// a non-empty array of objects. Other array cells keep their usual meaning.
+func objectRows(row []ast.Expression, values []interface{}) ([]map[string]interface{}, bool) {
+	if len(row) != 1 {
+		return nil, false
+	}
+	if call, ok := row[0].(*ast.CallExpression); !ok || call.Function.Value != "load" {
+		return nil, false
+	}
+	arr, ok := values[0].([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, false
+	}
+	objects := make([]map[string]interface{}, len(arr))
+	for i, item := range arr {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		objects[i] = obj
+	}
+	return objects, true
+}
+
+// evalDecimal applies an arithmetic operator with exact decimal semantics.
+// ok is false when an operand is not numeric.
+func (t *Transpiler) evalDecimal(node ast.Node, left interface{}, op string, right interface{}) (interface{}, bool, error) {
+	l, okL := toDecimal(left)
+	r, okR := toDecimal(right)
+	if !okL || !okR {
+		return nil, false, nil
+	}
+	switch op {
+	case "+":
+		return l.Add(r), true, nil
+	case "-":
+		return l.Sub(r), true, nil
+	case "*":
+		return l.Mul(r), true, nil
+	case "/":
+		if r.IsZero() {
+			return nil, true, t.errfNodeMsg(node, ie.DivisionByZero())
+		}
+		return l.Quo(r), true, nil
+	}
+	return nil, false, nil
+}
+
+// addInt64 returns a+b and whether the result fits in an int64
+func addInt64(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+// subInt64 returns a-b and whether the result fits in an int64
+func subInt64(a, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+// mulInt64 returns a*b and whether the result fits in an int64
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return c, false
+	}
+	return c, c/b == a
+}
+
+// evalPrefix applies a unary operator: '!' negates truthiness, '-' and '+' only accept numbers
+func (t *Transpiler) evalPrefix(node *ast.PrefixExpression, right interface{}) (interface{}, error) {
+	switch node.Operator {
+	case "!":
+		return !t.isTruthy(right), nil
+	case "-":
+		switch v := right.(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return nil, t.errfNodeMsg(node, ie.IntegerOverflow(fmt.Sprintf("-(%d)", v)))
+			}
+			return -v, nil
+		case float64:
+			return -v, nil
+		case Decimal:
+			return v.Neg(), nil
+		}
+	case "+":
+		switch v := right.(type) {
+		case int64, float64, Decimal:
+			return v, nil
+		}
+	}
+	operand := formatValue(right)
+	if str, ok := right.(string); ok {
+		operand = strconv.Quote(str)
+	}
+	return nil, t.errfNodeMsg(node, ie.UnsupportedPrefixOp(node.Operator, typeName(right), operand))
+}
+
+// typeName describes the kind of an evaluated value for error messages
+func typeName(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case Decimal:
+		return "decimal"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case RangeResult:
+		return "range"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// evalMember evaluates a member access. skipped reports that a '?.' link met
+// a null receiver, which makes the rest of the chain null too; links after a
+// receiver that merely holds null still fail.
+func (t *Transpiler) evalMember(ctx context.Context, e *ast.MemberExpression, scope *Scope) (val interface{}, skipped bool, err error) {
+	var leftVal interface{}
+	if left, ok := e.Left.(*ast.MemberExpression); ok {
+		if t.budget != nil {
+			if err := t.enterExpression(left); err != nil {
+				return nil, false, err
+			}
+			defer t.leaveExpression()
+		}
+		leftVal, skipped, err = t.evalMember(ctx, left, scope)
+		if err != nil || skipped {
+			return nil, skipped, err
+		}
+	} else {
+		leftVal, err = t.evalExpression(ctx, e.Left, scope)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if leftVal == nil && e.Optional {
+		return nil, true, nil
+	}
+
+	// Handle map access
+	if obj, ok := leftVal.(map[string]interface{}); ok {
+		if val, ok := obj[e.Property.Value]; ok {
+			return val, false, nil
+		}
+		if e.Optional {
+			return nil, false, nil
+		}
+		return nil, false, t.errfNodeMsg(e, ie.PropertyNotFound(e.Property.Value))
+	}
+	if e.Optional {
+		return nil, false, nil
+	}
+	return nil, false, t.errfNodeMsg(e, ie.NotAnObject())
+}
+
+// formatValue renders a value for string concatenation and interpolation
+func formatValue(val interface{}) string {
+	if val == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", val)
 }
 
 func toFloat(val interface{}) (float64, bool) {
@@ -835,6 +1230,8 @@ func (t *Transpiler) isTruthy(val interface{}) bool {
 		return v != 0
 	case float64:
 		return v != 0.0
+	case Decimal:
+		return !v.IsZero()
 	case string:
 		return v != ""
 	case nil:
@@ -845,6 +1242,18 @@ func (t *Transpiler) isTruthy(val interface{}) bool {
 }
 
 func (t *Transpiler) compareEqual(left, right interface{}) bool {
+	// null is only equal to null
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	// Decimals compare exactly against any numeric value
+	if isDecimal(left) || isDecimal(right) {
+		l, okL := toDecimal(left)
+		r, okR := toDecimal(right)
+		return okL && okR && l.Cmp(r) == 0
+	}
+
 	// Handle mixed numeric types
 	lFloat, lIsFloat := toFloat(left)
 	rFloat, rIsFloat := toFloat(right)
@@ -874,7 +1283,15 @@ func (t *Transpiler) compareEqual(left, right interface{}) bool {
 	return false
 }
 
-func (t *Transpiler) compareLess(left, right interface{}) (bool, error) {
+func (t *Transpiler) compareLess(node ast.Node, left, right interface{}) (bool, error) {
+	if isDecimal(left) || isDecimal(right) {
+		l, okL := toDecimal(left)
+		r, okR := toDecimal(right)
+		if okL && okR {
+			return l.Cmp(r) < 0, nil
+		}
+	}
+
 	// Handle mixed numeric types
 	lFloat, lIsFloat := toFloat(left)
 	rFloat, rIsFloat := toFloat(right)
@@ -896,12 +1313,12 @@ func (t *Transpiler) compareLess(left, right interface{}) (bool, error) {
 			return l < r, nil
 		}
 	}
-	return false, t.errMsg(ie.UnsupportedComparison(left, right))
+	return false, t.errfNodeMsg(node, ie.UnsupportedComparison(left, right))
 }
 
 // evalStringRange handles ranges of strings with numeric suffixes (e.g., IP addresses)
 // Example: "192.168.1.100".."192.168.1.109" generates ["192.168.1.100", "192.168.1.101", ...]
-func (t *Transpiler) evalStringRange(start, end string, stepV interface{}, node ast.Node) (interface{}, error) {
+func (t *Transpiler) evalStringRange(ctx context.Context, start, end string, stepV interface{}, node ast.Node) (interface{}, error) {
 	// Find the numeric suffix in both strings
 	// We'll look for the last sequence of digits
 	var startPrefix, endPrefix string
@@ -986,6 +1403,9 @@ func (t *Transpiler) evalStringRange(start, end string, stepV interface{}, node
 	res := make([]interface{}, 0)
 	if step > 0 {
 		for i := startNum; i <= endNum; i += step {
+			if err := t.addElement(ctx, node, len(res)); err != nil {
+				return nil, err
+			}
 			// Format with zero-padding if original had it
 			if padding > 1 && startStr[0] == '0' {
 				res = append(res, fmt.Sprintf("%s%0*d", startPrefix, padding, i))
@@ -995,6 +1415,9 @@ func (t *Transpiler) evalStringRange(start, end string, stepV interface{}, node
 		}
 	} else {
 		for i := startNum; i >= endNum; i += step {
+			if err := t.addElement(ctx, node, len(res)); err != nil {
+				return nil, err
+			}
 			if padding > 1 && startStr[0] == '0' {
 				res = append(res, fmt.Sprintf("%s%0*d", startPrefix, padding, i))
 			} else {
@@ -1008,6 +1431,28 @@ func (t *Transpiler) evalStringRange(start, end string, stepV interface{}, node
 
 // wtf???
 
+// SetResolver sets how include paths and load() files are found and read,
+// e.g. an FSResolver over an embed.FS. The default is OSResolver.
+func (t *Transpiler) SetResolver(r IncludeResolver) {
+	if r == nil {
+		r = OSResolver{}
+	}
+	t.resolver = r
+}
+
+// SetIncludeLimits caps how deeply includes may nest and how many files
+// (includes and load() data) one run may read. Zero means unlimited.
+func (t *Transpiler) SetIncludeLimits(maxDepth, maxFiles int) {
+	t.maxIncludeDepth = maxDepth
+	t.maxIncludeFiles = maxFiles
+}
+
+// SetDecimalMode enables exact decimal arithmetic: float literals keep their
+// written precision and serialise without binary floating-point noise
+func (t *Transpiler) SetDecimalMode(enabled bool) {
+	t.decimalMode = enabled
+}
+
 // SetStreamingMode configures streaming behavior
 func (t *Transpiler) SetStreamingMode(enabled bool, threshold int64) {
 	t.streamingEnabled = enabled
@@ -1019,11 +1464,11 @@ func (t *Transpiler) SetStreamingMode(enabled bool, threshold int64) {
 // estimateRangeSize calculates the size of a range expression
 func (t *Transpiler) estimateRangeSize(e *ast.RangeExpression) int64 {
 	// Try to evaluate start and end as constants
-	startV, err := t.evalExpression(e.Start, nil)
+	startV, err := t.evalExpression(context.Background(), e.Start, nil)
 	if err != nil {
 		return 0
 	}
-	endV, err := t.evalExpression(e.End, nil)
+	endV, err := t.evalExpression(context.Background(), e.End, nil)
 	if err != nil {
 		return 0
 	}
@@ -1036,7 +1481,7 @@ func (t *Transpiler) estimateRangeSize(e *ast.RangeExpression) int64 {
 
 	step := int64(1)
 	if e.Step != nil {
-		stepV, err := t.evalExpression(e.Step, nil)
+		stepV, err := t.evalExpression(context.Background(), e.Step, nil)
 		if err == nil {
 			if st, ok := stepV.(int64); ok {
 				step = st
@@ -228,3 +228,122 @@ func TestIncludeMergeErrorMode(t *testing.T) {
 		t.Fatalf("unexpected error message: %v", err)
 	}
 }
+
+func TestIncludeGlobMergesInSortedOrder(t *testing.T) {
+	files := map[string]string{
+		"services/b.jsson":  "name = \"b\"\nb = true\n",
+		"services/a.jsson":  "name = \"a\"\na = true\n",
+		"services/notes.md": "not jsson",
+	}
+	root, err := transpileWithFiles(t, files, `include "services/*.jsson"`)
+	if err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+	// keep mode: the first file in sorted order wins
+	if root["name"] != "a" || root["a"] != true || root["b"] != true {
+		t.Fatalf("unexpected glob merge result: %v", root)
+	}
+}
+
+func TestIncludeDirectoryKeyed(t *testing.T) {
+	files := map[string]string{
+		"services/billing.jsson": "port = 8081\n",
+		"services/auth.jsson":    "port = 8080\n",
+	}
+	root, err := transpileWithFiles(t, files, `include "services" keyed`)
+	if err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+	auth, ok := root["auth"].(map[string]interface{})
+	if !ok || auth["port"] != float64(8080) {
+		t.Fatalf("expected auth nested under its file name, got %v", root)
+	}
+	if billing, ok := root["billing"].(map[string]interface{}); !ok || billing["port"] != float64(8081) {
+		t.Fatalf("expected billing nested under its file name, got %v", root)
+	}
+}
+
+func TestIncludeGlobErrors(t *testing.T) {
+	files := map[string]string{
+		"eu/db.jsson": "host = \"eu\"\n",
+		"us/db.jsson": "host = \"us\"\n",
+		"loop.jsson":  "include \"*.jsson\"\n",
+		"self.jsson":  "include \"loop.jsson\"\n",
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`include "missing/*.jsson"`, "matched no files"},
+		{`include "*/db.jsson" keyed`, `both want the key "db"`},
+		{`include "loop.jsson"`, "cyclic include"},
+	}
+	for _, tt := range tests {
+		_, err := transpileWithFiles(t, files, tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.want, err)
+		}
+	}
+}
+
+func TestIncludeMountedUnderKey(t *testing.T) {
+	files := map[string]string{
+		"db.jsson":    "host = \"db.local\"\nport = 5432\n",
+		"cache.jsson": "host = \"redis.local\"\nport = 6379\n",
+	}
+	input := `
+include "db.jsson" as database
+include "cache.jsson" as services.cache
+include "db.jsson" as services.primary
+`
+	root, err := transpileWithFiles(t, files, input)
+	if err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+	if _, leaked := root["host"]; leaked {
+		t.Fatalf("mounted include leaked into the root: %v", root)
+	}
+	db := root["database"].(map[string]interface{})
+	if db["host"] != "db.local" || db["port"] != float64(5432) {
+		t.Fatalf("unexpected database mount: %v", db)
+	}
+	services := root["services"].(map[string]interface{})
+	if services["cache"].(map[string]interface{})["host"] != "redis.local" {
+		t.Fatalf("unexpected nested mount: %v", services)
+	}
+	if services["primary"].(map[string]interface{})["port"] != float64(5432) {
+		t.Fatalf("expected sibling mounts under services, got %v", services)
+	}
+}
+
+func TestIncludeInsideObjectBody(t *testing.T) {
+	files := map[string]string{
+		"defaults.jsson": "timeout = 30\nretries = 3\n",
+	}
+	input := `
+api {
+  retries = 5
+  include "defaults.jsson"
+}
+`
+	root, err := transpileWithFiles(t, files, input)
+	if err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+	api := root["api"].(map[string]interface{})
+	// keep mode: the object's own properties win over included ones
+	if api["timeout"] != float64(30) || api["retries"] != float64(5) {
+		t.Fatalf("unexpected object include result: %v", api)
+	}
+	if _, leaked := root["timeout"]; leaked {
+		t.Fatalf("object include leaked into the root: %v", root)
+	}
+}
+
+func TestIncludeMountConflicts(t *testing.T) {
+	files := map[string]string{"db.jsson": "host = \"db.local\"\n"}
+	_, err := transpileWithFiles(t, files, "database = 1\ninclude \"db.jsson\" as database.primary")
+	if err == nil || !strings.Contains(err.Error(), "can't mount include at database") {
+		t.Fatalf("expected mount error, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package transpiler
+
+import (
+	"context"
+	"encoding/json"
+	"jsson/internal/ast"
+	ie "jsson/internal/errors"
+)
+
+// compileTypes turns the program's type declarations into schemas, so the
+// annotations that use them can be checked with the schema validator.
+// Declarations may refer to each other in any order, and to themselves.
+func (t *Transpiler) compileTypes() error {
+	t.types = make(map[string]*Schema)
+	var decls []*ast.TypeDeclaration
+	for _, stmt := range t.program.Statements {
+		decl, ok := stmt.(*ast.TypeDeclaration)
+		if !ok {
+			continue
+		}
+		if _, dup := t.types[decl.Name.Value]; dup || builtinType(decl.Name.Value) != nil {
+			return t.errfNodeMsg(decl.Name, ie.DuplicateType(decl.Name.Value))
+		}
+		// Start empty: references taken before the body is compiled share the pointer
+		t.types[decl.Name.Value] = &Schema{}
+		decls = append(decls, decl)
+	}
+	for _, decl := range decls {
+		s, err := t.typeSchema(decl.Type)
+		if err != nil {
+			return err
+		}
+		s.Description = decl.Doc
+		*t.types[decl.Name.Value] = *s
+	}
+	// A type may contain itself (type Node { children: [Node] }) but can't be
+	// itself without any structure in between, or checking would never end
+	for _, decl := range decls {
+		if refersToItself(t.types[decl.Name.Value], t.types[decl.Name.Value], map[*Schema]bool{}) {
+			return t.errfNodeMsg(decl.Name, ie.CyclicType(decl.Name.Value))
+		}
+	}
+	return nil
+}
+
+// refersToItself follows the references and combinators of s, which check the
+// same value again, looking for target
+func refersToItself(s, target *Schema, seen map[*Schema]bool) bool {
+	if seen[s] {
+		return false
+	}
+	seen[s] = true
+	next := append([]*Schema{s.ref, s.Not}, s.AnyOf...)
+	next = append(next, s.AllOf...)
+	next = append(next, s.OneOf...)
+	for _, n := range next {
+		if n == nil {
+			continue
+		}
+		if n == target || refersToItself(n, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinType returns the schema of a built-in type name, or nil
+func builtinType(name string) *Schema {
+	switch name {
+	case "string":
+		return &Schema{Type: SchemaTypes{"string"}}
+	case "int":
+		return &Schema{Type: SchemaTypes{"integer"}}
+	case "float", "number":
+		return &Schema{Type: SchemaTypes{"number"}}
+	case "bool":
+		return &Schema{Type: SchemaTypes{"boolean"}}
+	case "null":
+		return &Schema{Type: SchemaTypes{"null"}}
+	case "any":
+		return &Schema{}
+	}
+	return nil
+}
+
+// typeSchema compiles a type expression
+func (t *Transpiler) typeSchema(typ ast.TypeExpression) (*Schema, error) {
+	switch ty := typ.(type) {
+	case *ast.NamedType:
+		if s := builtinType(ty.Name); s != nil {
+			if ty.Bounded {
+				if err := t.applyBounds(s, ty); err != nil {
+					return nil, err
+				}
+			}
+			return s, nil
+		}
+		decl, ok := t.types[ty.Name]
+		if !ok {
+			return nil, t.errfNodeMsg(ty, ie.UnknownType(ty.Name))
+		}
+		if ty.Bounded {
+			return nil, t.errfNodeMsg(ty, ie.BoundsNotSupported(ty.Name))
+		}
+		return &Schema{Ref: "#/$defs/" + ty.Name, ref: decl}, nil
+	case *ast.LiteralType:
+		val, err := t.evalExpression(context.Background(), ty.Value, nil)
+		if err != nil {
+			return nil, err
+		}
+		// Enum values are compared as decoded JSON, where every number is a float64
+		switch v := val.(type) {
+		case int64:
+			val = float64(v)
+		case Decimal:
+			val, _ = v.rat.Float64()
+		}
+		return &Schema{Enum: []interface{}{val}}, nil
+	case *ast.UnionType:
+		return t.unionSchema(ty)
+	case *ast.ArrayType:
+		elem, err := t.typeSchema(ty.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: SchemaTypes{"array"}, Items: elem}, nil
+	case *ast.ObjectType:
+		s := &Schema{
+			Type:       SchemaTypes{"object"},
+			Properties: make(map[string]*Schema, len(ty.Fields)),
+			// Keys a type doesn't list are mistakes, usually typos
+			AdditionalProperties: &Schema{Not: &Schema{}},
+		}
+		for _, f := range ty.Fields {
+			if _, dup := s.Properties[f.Name]; dup {
+				return nil, t.errfNodeMsg(ty, ie.DuplicateTypeField(f.Name))
+			}
+			fs, err := t.typeSchema(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			s.Properties[f.Name] = fs
+			if !f.Optional {
+				s.Required = append(s.Required, f.Name)
+			}
+		}
+		return s, nil
+	}
+	return nil, t.errMsg(ie.TypeExpected(typ.String()))
+}
+
+// unionSchema compiles A | B. Literal options collapse into one enum and
+// plain built-ins into a type list, which give clearer errors than anyOf.
+func (t *Transpiler) unionSchema(u *ast.UnionType) (*Schema, error) {
+	enum := &Schema{}
+	types := &Schema{}
+	var rest []*Schema
+	for _, opt := range u.Options {
+		s, err := t.typeSchema(opt)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(s.Enum) == 1 && len(s.Type) == 0:
+			enum.Enum = append(enum.Enum, s.Enum...)
+		case isPlainType(s):
+			types.Type = append(types.Type, s.Type...)
+		default:
+			rest = append(rest, s)
+		}
+	}
+
+	var parts []*Schema
+	if len(enum.Enum) > 0 {
+		parts = append(parts, enum)
+	}
+	if len(types.Type) > 0 {
+		parts = append(parts, types)
+	}
+	parts = append(parts, rest...)
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return &Schema{AnyOf: parts}, nil
+}
+
+// isPlainType reports whether s is just a type keyword, e.g. from 'string'
+func isPlainType(s *Schema) bool {
+	if len(s.Type) != 1 {
+		return false
+	}
+	got, _ := json.Marshal(s)
+	plain, _ := json.Marshal(&Schema{Type: s.Type})
+	return string(got) == string(plain)
+}
+
+// applyBounds turns int(1..50) into minimum/maximum and string(1..63) into
+// length limits
+func (t *Transpiler) applyBounds(s *Schema, ty *ast.NamedType) error {
+	min, max, err := t.typeBounds(ty)
+	if err != nil {
+		return err
+	}
+	if min != nil && max != nil && *min > *max {
+		return t.errfNodeMsg(ty, ie.BoundsReversed(ty.String()))
+	}
+	switch ty.Name {
+	case "int", "float", "number":
+		s.Minimum, s.Maximum = min, max
+	case "string":
+		if min != nil {
+			n := int(*min)
+			s.MinLength = &n
+		}
+		if max != nil {
+			n := int(*max)
+			s.MaxLength = &n
+		}
+	default:
+		return t.errfNodeMsg(ty, ie.BoundsNotSupported(ty.Name))
+	}
+	return nil
+}
+
+func (t *Transpiler) typeBounds(ty *ast.NamedType) (*float64, *float64, error) {
+	bound := func(expr ast.Expression) (*float64, error) {
+		if expr == nil {
+			return nil, nil
+		}
+		val, err := t.evalExpression(context.Background(), expr, nil)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := schemaNumber(val)
+		if !ok {
+			return nil, t.errfNodeMsg(expr, ie.TypeBoundExpected(expr.String()))
+		}
+		return &f, nil
+	}
+	min, err := bound(ty.Min)
+	if err != nil {
+		return nil, nil, err
+	}
+	max, err := bound(ty.Max)
+	return min, max, err
+}
+
+// checkAnnotation checks the value of an annotated assignment against its
+// type. On an array template the type describes each row.
+func (t *Transpiler) checkAnnotation(ctx context.Context, s *ast.AssignmentStatement, val interface{}) error {
+	schema, err := t.typeSchema(s.Type)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.Value.(*ast.ArrayTemplate); ok {
+		schema = &Schema{Type: SchemaTypes{"array"}, Items: schema}
+	}
+
+	c := &schemaCheck{ctx: ctx}
+	c.check(schema, val, []interface{}{s.Name.Value})
+	if c.err != nil {
+		return t.checkContext(ctx, s)
+	}
+	if c.found == 0 {
+		return nil
+	}
+	return t.violationError(c, func(path []interface{}) (int, int) {
+		return t.locateFrom(s.Value, val, path[1:], s.Token.Line, s.Token.Column)
+	}, func(path, problem string) string {
+		return ie.TypeViolation(path, problem, s.Type.String())
+	})
+}
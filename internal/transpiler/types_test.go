@@ -0,0 +1,112 @@
+package transpiler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func transpileTyped(t *testing.T, input string) error {
+	t.Helper()
+	_, err := newTestTranspiler(t, input, withSourceFile("main.jsson")).Transpile()
+	return err
+}
+
+const deploymentType = `type Deployment {
+  name: string(1..63)
+  replicas: int(1..50)
+  env: "prod" | "staging" | "dev"
+  labels?: { app: string }
+}
+`
+
+func TestTypes_ValidValuesPass(t *testing.T) {
+	input := deploymentType + `
+type Node { name: string, children?: [Node] }
+schema Ratio = float(0..1) | null
+
+deploy: Deployment {
+  name = "api"
+  replicas = 3
+  env = "prod"
+}
+tree: Node = { name = "root", children = [ { name = "leaf" } ] }
+ratio: Ratio = null
+ports: [int(1..65535)] = [ 80, 443 ]
+users: { id: int, role: "admin" | "user" } [
+  template { id, role }
+  1, "admin"
+  2, "user"
+]`
+	if err := transpileTyped(t, input); err != nil {
+		t.Fatalf("Expected typed values to pass, got %v", err)
+	}
+}
+
+func TestTypes_ViolationsArePositioned(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		line  int
+		want  string
+	}{
+		{"bound", deploymentType + "deploy: Deployment {\n  name = \"api\"\n  replicas = 80\n  env = \"prod\"\n}", "deploy.replicas", 9, "should be at most 50, got 80"},
+		{"enum", deploymentType + "deploy: Deployment {\n  name = \"api\"\n  replicas = 3\n  env = \"qa\"\n}", "deploy.env", 10, `should be one of "prod", "staging", "dev"`},
+		{"missing field", deploymentType + "deploy: Deployment {\n  name = \"api\"\n  env = \"dev\"\n}", "deploy", 7, `missing the required key "replicas"`},
+		{"unknown field", deploymentType + "deploy: Deployment {\n  name = \"api\"\n  replicas = 1\n  env = \"dev\"\n  replica = 2\n}", "deploy.replica", 11, "is not allowed here"},
+		{"string length", deploymentType + "deploy: Deployment {\n  name = \"\"\n  replicas = 1\n  env = \"dev\"\n}", "deploy.name", 8, "at least 1 characters"},
+		{"template row", "users: { id: int } [\n  template { id }\n  1\n  \"two\"\n]", "users[1].id", 4, "should be integer, got string"},
+		{"union bound", "ratio: float(0..1) | null = 1.5", "ratio", 1, "should be at most 1, got 1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := transpileTyped(t, tt.input)
+			var serr *SchemaError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Expected a SchemaError, got %v", err)
+			}
+			v := serr.Violations[0]
+			if v.Path != tt.path || v.Line != tt.line || !strings.Contains(v.Problem, tt.want) {
+				t.Errorf("Expected %s on line %d (%s), got %+v", tt.path, tt.line, tt.want, v)
+			}
+			if !strings.Contains(err.Error(), "doesn't fit its type") {
+				t.Errorf("Expected a type error message, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTypes_DeclarationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unknown", "x: Missing = 1", `unknown type "Missing"`},
+		{"duplicate", "type A = int\ntype A = string", "declared more than once"},
+		{"builtin", "type int = string", "declared more than once"},
+		{"duplicate field", "type A { x: int, x: string }\ny: A = { x = 1 }", "field x appears twice"},
+		{"reversed bounds", "x: int(10..1) = 5", "minimum above its maximum"},
+		{"bounds on bool", "x: bool(0..1) = true", "bool can't take bounds"},
+		{"self alias", "type A = A | null\nx: A = 1", "defined as itself"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := transpileTyped(t, tt.input); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestTypes_DeclarationsStayOutOfOutput(t *testing.T) {
+	out, err := newTestTranspiler(t, "type Port = int(1..65535)\nport: Port = 8080\ntype = \"web\"").Transpile()
+	if err != nil {
+		t.Fatalf("Transpile error: %v", err)
+	}
+	if got := strings.Join(strings.Fields(string(out)), " "); got != `{ "port": 8080, "type": "web" }` {
+		t.Errorf("Unexpected output: %s", got)
+	}
+}
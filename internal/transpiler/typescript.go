@@ -2,146 +2,215 @@ package transpiler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"jsson/internal/ast"
-	ie "jsson/internal/errors"
-	"jsson/internal/lexer"
-	"jsson/internal/parser"
-	"os"
-	"path/filepath"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-// TranspileToTypeScript converts the transpiled data to TypeScript format with types
-func (t *Transpiler) TranspileToTypeScript() ([]byte, error) {
-	root := make(map[string]interface{})
-
-	for _, stmt := range t.program.Statements {
-		switch s := stmt.(type) {
-		case *ast.VariableDeclaration:
-			// Variable declarations are stored in symbol table but not added to output
-			val, err := t.evalExpression(s.Value, nil)
-			if err != nil {
-				return nil, err
-			}
-			t.symbolTable[s.Name.Value] = val
-		case *ast.AssignmentStatement:
-			key := s.Name.Value
-			val, err := t.evalExpression(s.Value, nil)
-			if err != nil {
-				return nil, err
-			}
-			root[key] = val
-		case *ast.IncludeStatement:
-			// Handle includes (same logic as other transpilers)
-			includePath := s.Path.Value
-			var includeAbs string
-			if filepath.IsAbs(includePath) {
-				includeAbs = filepath.Clean(includePath)
-			} else {
-				includeAbs = filepath.Clean(filepath.Join(t.baseDir, includePath))
-			}
+// TypeScript output modes, set with SetTypeScriptMode
+const (
+	// TypeScriptConst exports each top-level key as an 'as const' value with a
+	// typeof alias for its type
+	TypeScriptConst = "const"
+	// TypeScriptInterfaces exports interfaces inferred from the document and
+	// each top-level key as a value of its interface
+	TypeScriptInterfaces = "interfaces"
+	// TypeScriptDeclarations exports the interfaces only, for a .d.ts that
+	// describes JSON loaded at runtime
+	TypeScriptDeclarations = "dts"
+)
 
-			if t.inProgress[includeAbs] {
-				return nil, t.errfNodeMsg(s, ie.CyclicInclude(includeAbs))
-			}
+// tsReserved are the words that can't name a const
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"let": true, "static": true, "yield": true, "await": true, "implements": true,
+	"interface": true, "package": true, "private": true, "protected": true, "public": true,
+}
 
-			if cached, ok := t.includeCache[includeAbs]; ok {
-				for k, v := range cached {
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				}
-				break
-			}
+// tsIdentifier matches the keys that can be written unquoted as property names
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
 
-			t.inProgress[includeAbs] = true
+// SetTypeScriptMode chooses what TranspileToTypeScript writes: TypeScriptConst
+// (the default), TypeScriptInterfaces or TypeScriptDeclarations
+func (t *Transpiler) SetTypeScriptMode(mode string) {
+	t.tsMode = mode
+}
 
-			data, err := os.ReadFile(includeAbs)
-			if err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "could not read include file %q — gremlin can't find it: %v", s.Path.Value, err)
-			}
+// TranspileToTypeScript converts the transpiled data to TypeScript format with types
+func (t *Transpiler) TranspileToTypeScript() ([]byte, error) {
+	return t.TranspileToTypeScriptContext(context.Background())
+}
 
-			l := lexer.New(string(data))
-			l.SetSourceFile(includeAbs)
-			p := parser.New(l)
-			prog := p.ParseProgram()
-			if len(p.Errors()) > 0 {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "parser errors in included file %q — wizard got confused: %v", s.Path.Value, p.Errors())
-			}
+// TranspileToTypeScriptContext is TranspileToTypeScript that stops with ctx's
+// error once ctx is done
+func (t *Transpiler) TranspileToTypeScriptContext(ctx context.Context) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-			incBase := filepath.Dir(includeAbs)
-			incT := New(prog, incBase, t.mergeMode, includeAbs)
-			incT.includeCache = t.includeCache
-			incT.inProgress = t.inProgress
+	// Generate TypeScript code
+	var buf bytes.Buffer
 
-			incJSON, err := incT.Transpile()
-			if err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "transpile error in included file %q: %v", s.Path.Value, err)
-			}
+	docs := t.collectDocs()
+	keys := slices.Sorted(maps.Keys(root))
 
-			var incRoot map[string]interface{}
-			if err := json.Unmarshal(incJSON, &incRoot); err != nil {
-				t.inProgress[includeAbs] = false
-				return nil, t.errfNode(s, "invalid json from include %q: %v", s.Path.Value, err)
-			}
+	// Keys become const names, which must be identifiers and can't repeat
+	consts := make(map[string]string, len(keys))
+	taken := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		consts[key] = uniqueIdent(taken, tsConstName(key))
+	}
 
-			t.includeCache[includeAbs] = incRoot
-			t.inProgress[includeAbs] = false
-
-			for k, v := range incRoot {
-				switch t.mergeMode {
-				case "keep":
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				case "overwrite":
-					root[k] = v
-				case "error":
-					if _, exists := root[k]; exists {
-						return nil, t.errfNode(s, "include merge conflict for key %q from %s", k, includeAbs)
-					}
-					root[k] = v
-				default:
-					if _, exists := root[k]; !exists {
-						root[k] = v
-					}
-				}
+	if t.tsMode == TypeScriptInterfaces || t.tsMode == TypeScriptDeclarations {
+		m, err := t.inferTypeModel(ctx, root)
+		if err != nil {
+			return t.limitOutput(ctx, nil, err)
+		}
+		writeTypeScriptInterfaces(&buf, m)
+		if t.tsMode == TypeScriptDeclarations {
+			return t.limitOutput(ctx, buf.Bytes(), nil)
+		}
+		for _, key := range keys {
+			buf.WriteString("\n")
+			writeJSDoc(&buf, docs[key], "")
+			buf.WriteString(fmt.Sprintf("export const %s: %s = ", consts[key], m.tsType(m.root.props[key])))
+			if err := writeTypeScriptValue(&buf, root[key], 0, key, docs); err != nil {
+				return t.limitOutput(ctx, nil, err)
 			}
+			buf.WriteString(";\n")
 		}
+		return t.limitOutput(ctx, buf.Bytes(), nil)
 	}
 
-	// Generate TypeScript code
-	var buf bytes.Buffer
-
 	// Write exports for each top-level key
-	for key, value := range root {
-		buf.WriteString(fmt.Sprintf("export const %s = ", key))
-		writeTypeScriptValue(&buf, value, 0)
+	for _, key := range keys {
+		writeJSDoc(&buf, docs[key], "")
+		buf.WriteString(fmt.Sprintf("export const %s = ", consts[key]))
+		if err := writeTypeScriptValue(&buf, root[key], 0, key, docs); err != nil {
+			return t.limitOutput(ctx, nil, err)
+		}
 		buf.WriteString(" as const;\n\n")
 	}
 
 	// Generate type exports
 	buf.WriteString("// Generated types\n")
-	for key := range root {
-		buf.WriteString(fmt.Sprintf("export type %s = typeof %s;\n", capitalize(key), key))
+	aliases := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		alias := uniqueIdent(aliases, capitalize(consts[key]))
+		buf.WriteString(fmt.Sprintf("export type %s = typeof %s;\n", alias, consts[key]))
 	}
 
-	return buf.Bytes(), nil
+	return t.limitOutput(ctx, buf.Bytes(), nil)
+}
+
+// tsConstName turns a key into a const name. Keys that already are
+// identifiers keep their spelling.
+func tsConstName(key string) string {
+	if tsIdentifier.MatchString(key) {
+		if tsReserved[key] {
+			return key + "_"
+		}
+		return key
+	}
+	name := camelIdent(key)
+	if name == "" || tsReserved[name] {
+		name += "_"
+	}
+	return name
 }
 
-func writeTypeScriptValue(buf *bytes.Buffer, value interface{}, indent int) {
+// tsPropertyName writes a key as a property name, quoted unless it's an identifier
+func tsPropertyName(key string) string {
+	if tsIdentifier.MatchString(key) {
+		return key
+	}
+	return quoteTypeScriptString(key)
+}
+
+// writeTypeScriptInterfaces writes an interface for every record of m
+func writeTypeScriptInterfaces(buf *bytes.Buffer, m *typeModel) {
+	for i, r := range m.types {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(fmt.Sprintf("export interface %s {\n", r.name))
+		for _, f := range r.fields() {
+			writeJSDoc(buf, f.doc, "  ")
+			optional := ""
+			if f.optional {
+				optional = "?"
+			}
+			buf.WriteString(fmt.Sprintf("  %s%s: %s;\n", tsPropertyName(f.key), optional, m.tsType(f.shape)))
+		}
+		buf.WriteString("}\n")
+	}
+}
+
+// tsType spells the type of a position: the union of everything seen there,
+// with small repeated string sets as literal unions
+func (m *typeModel) tsType(s *shape) string {
+	types, nullable := s.valueTypes()
+	var parts []string
+	for _, typ := range types {
+		switch typ {
+		case "object":
+			parts = append(parts, m.names[s])
+		case "array":
+			elem := "unknown"
+			if s.items != nil {
+				elem = m.tsType(s.items)
+			}
+			if strings.Contains(elem, " | ") {
+				elem = "(" + elem + ")"
+			}
+			parts = append(parts, elem+"[]")
+		case "string":
+			values := s.enumValues()
+			if values == nil {
+				parts = append(parts, "string")
+			}
+			for _, v := range values {
+				parts = append(parts, quoteTypeScriptString(v))
+			}
+		case "integer", "number":
+			parts = append(parts, "number")
+		case "boolean":
+			parts = append(parts, "boolean")
+		}
+	}
+	if nullable {
+		parts = append(parts, "null")
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, " | ")
+}
+
+// writeTypeScriptValue writes value as a TypeScript literal. path is the dotted
+// output path of value, used to look up doc comments for object properties.
+// The only errors come from ranges, which stop once the run is done or the
+// output limit is passed.
+func writeTypeScriptValue(buf *bytes.Buffer, value interface{}, indent int, path string, docs map[string]string) error {
 	indentStr := strings.Repeat("  ", indent)
 
 	switch v := value.(type) {
 	case string:
-		// Escape quotes and write as string literal
-		escaped := strings.ReplaceAll(v, "\"", "\\\"")
-		buf.WriteString(fmt.Sprintf("\"%s\"", escaped))
+		// JSON string literals are valid TypeScript and escape everything we need
+		buf.WriteString(quoteTypeScriptString(v))
 	case int64:
 		buf.WriteString(fmt.Sprintf("%d", v))
 	case float64:
@@ -152,21 +221,39 @@ func writeTypeScriptValue(buf *bytes.Buffer, value interface{}, indent int) {
 		buf.WriteString("null")
 	case map[string]interface{}:
 		buf.WriteString("{\n")
-		first := true
-		for k, val := range v {
-			if !first {
+		for i, k := range slices.Sorted(maps.Keys(v)) {
+			if i > 0 {
 				buf.WriteString(",\n")
 			}
-			first = false
-			buf.WriteString(fmt.Sprintf("%s  %s: ", indentStr, k))
-			writeTypeScriptValue(buf, val, indent+1)
+			writeJSDoc(buf, docs[joinPath(path, k)], indentStr+"  ")
+			buf.WriteString(fmt.Sprintf("%s  %s: ", indentStr, tsPropertyName(k)))
+			if err := writeTypeScriptValue(buf, v[k], indent+1, joinPath(path, k), docs); err != nil {
+				return err
+			}
 		}
 		buf.WriteString(fmt.Sprintf("\n%s}", indentStr))
+	case RangeResult:
+		buf.WriteString("[\n")
+		err := v.expand(func(i int, n int64) int {
+			start := buf.Len()
+			buf.WriteString(indentStr + "  " + strconv.FormatInt(n, 10))
+			if i < v.Len()-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+			return buf.Len() - start
+		})
+		if err != nil {
+			return err
+		}
+		buf.WriteString(indentStr + "]")
 	case []interface{}:
 		buf.WriteString("[\n")
 		for i, val := range v {
 			buf.WriteString(indentStr + "  ")
-			writeTypeScriptValue(buf, val, indent+1)
+			if err := writeTypeScriptValue(buf, val, indent+1, "", nil); err != nil {
+				return err
+			}
 			if i < len(v)-1 {
 				buf.WriteString(",")
 			}
@@ -176,6 +263,37 @@ func writeTypeScriptValue(buf *bytes.Buffer, value interface{}, indent int) {
 	default:
 		buf.WriteString(fmt.Sprintf("%v", v))
 	}
+	return nil
+}
+
+// writeJSDoc writes a doc comment as a JSDoc block; nothing is written for an empty doc
+func writeJSDoc(buf *bytes.Buffer, doc string, indent string) {
+	if doc == "" {
+		return
+	}
+	// A literal "*/" would end the comment early
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		buf.WriteString(fmt.Sprintf("%s/** %s */\n", indent, lines[0]))
+		return
+	}
+	buf.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		buf.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	buf.WriteString(indent + " */\n")
+}
+
+// quoteTypeScriptString writes s as a double-quoted literal without HTML escaping
+func quoteTypeScriptString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
 func capitalize(s string) string {
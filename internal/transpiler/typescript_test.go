@@ -0,0 +1,64 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeScript_Interfaces(t *testing.T) {
+	input := `/// Service name
+name = "api"
+users = [
+  { id = 1, role = "admin", email = "a@x.io" }
+  { id = 2, role = "user" }
+  { id = 3.5, role = "user", email = null }
+]
+primary { server { host = "a" } }
+backup { server { port = 1 } }`
+	out := generate(t, input, nil, func(tr *Transpiler) { tr.SetTypeScriptMode(TypeScriptInterfaces) }, (*Transpiler).TranspileToTypeScript)
+
+	for _, want := range []string{
+		"export interface User {\n  email?: string | null;\n  id: number;\n  role: \"admin\" | \"user\";\n}",
+		"export interface Server {\n  port: number;\n}",
+		"export interface PrimaryServer {\n  host: string;\n}",
+		"  /** Service name */\n  name: string;\n",
+		"  users: User[];\n",
+		"export const users: User[] = [",
+		"/** Service name */\nexport const name: string = \"api\";",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "as const") || strings.Contains(out, "typeof") {
+		t.Errorf("Interfaces mode shouldn't write const assertions, got:\n%s", out)
+	}
+}
+
+func TestTypeScript_DeclarationsOnly(t *testing.T) {
+	out := generate(t, "port = 8080\ntags = [ \"a\", 1 ]\nempty = []", nil, func(tr *Transpiler) { tr.SetTypeScriptMode(TypeScriptDeclarations) }, (*Transpiler).TranspileToTypeScript)
+	want := "export interface Config {\n  empty: unknown[];\n  port: number;\n  tags: (number | string)[];\n}\n"
+	if out != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestTypeScript_KeysBecomeValidIdentifiers(t *testing.T) {
+	files := map[string]string{"data.json": `{"my-key": 1, "2fa": true, "nested": {"max-conns": 4}}`}
+	input := "default = true\nclass = 3\nitem = 1\nItem = 2\ndata = load(\"data.json\")"
+	out := generate(t, input, files, nil, (*Transpiler).TranspileToTypeScript)
+
+	for _, want := range []string{
+		"export const default_ = true as const;",
+		"export const class_ = 3 as const;",
+		"  \"2fa\": true,\n",
+		"  \"my-key\": 1,\n",
+		"    \"max-conns\": 4\n",
+		"export type Item = typeof Item;\nexport type Class_ = typeof class_;",
+		"export type Item2 = typeof item;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
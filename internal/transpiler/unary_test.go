@@ -0,0 +1,70 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnary_LogicalNot(t *testing.T) {
+	input := `
+enabled := true
+disabled = !enabled
+emptyIsFalsy = !""
+doubleNot = !!1
+negatedComparison = !(1 > 2)
+`
+	root := transpileToMap(t, input)
+
+	expected := map[string]bool{
+		"disabled":          false,
+		"emptyIsFalsy":      true,
+		"doubleNot":         true,
+		"negatedComparison": true,
+	}
+	for key, want := range expected {
+		if root[key] != want {
+			t.Errorf("Expected %s=%v, got: %v", key, want, root[key])
+		}
+	}
+}
+
+func TestUnary_MinusAndPlus(t *testing.T) {
+	input := `
+x := 5
+price := 2.5
+negInt = -x
+negFloat = -price
+plus = +x
+negGroup = -(x * 2)
+mixed = 10 - -x
+`
+	root := transpileToMap(t, input)
+
+	expected := map[string]float64{
+		"negInt":   -5,
+		"negFloat": -2.5,
+		"plus":     5,
+		"negGroup": -10,
+		"mixed":    15,
+	}
+	for key, want := range expected {
+		if root[key] != want {
+			t.Errorf("Expected %s=%v, got: %v", key, want, root[key])
+		}
+	}
+}
+
+func TestUnary_MinusOnStringFails(t *testing.T) {
+	input := `name := "a"
+data = -name`
+	_, err := newTestTranspiler(t, input).Transpile()
+	if err == nil {
+		t.Fatal("Expected error for unary minus on string")
+	}
+	if !strings.Contains(err.Error(), "unary -") || !strings.Contains(err.Error(), "string") {
+		t.Errorf("Expected unary operator error, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "binary") {
+		t.Errorf("Unary error should not mention binary operations: %v", err)
+	}
+}
@@ -0,0 +1,603 @@
+package transpiler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jsson/internal/ast"
+	ie "jsson/internal/errors"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSchemaViolations caps how many violations one run reports in full
+const maxSchemaViolations = 20
+
+// SetSchema checks the evaluated document against s before any output is
+// encoded, so a config that breaks its schema never gets written. Violations
+// point at the JSSON source of the offending property. nil turns it off.
+func (t *Transpiler) SetSchema(s *Schema) {
+	t.schema = s
+}
+
+// ParseSchema reads a JSON Schema for SetSchema. Local references ("#" and
+// "#/$defs/...") are resolved and patterns compiled up front.
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, (*Transpiler)(nil).errMsg(ie.InvalidSchema(err.Error()))
+	}
+	if err := s.prepare(&s); err != nil {
+		return nil, (*Transpiler)(nil).errMsg(ie.InvalidSchema(err.Error()))
+	}
+	return &s, nil
+}
+
+// schemaTypeNames are the values the "type" keyword may take
+var schemaTypeNames = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// prepare resolves references and compiles patterns in s and its subschemas
+func (s *Schema) prepare(root *Schema) error {
+	for _, typ := range s.Type {
+		if !schemaTypeNames[typ] {
+			return fmt.Errorf("unknown type %q", typ)
+		}
+	}
+	if s.Ref != "" {
+		target, err := root.resolve(s.Ref)
+		if err != nil {
+			return err
+		}
+		s.ref = target
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	if len(s.Const) > 0 {
+		if err := json.Unmarshal(s.Const, &s.constV); err != nil {
+			return err
+		}
+	}
+
+	var subs []*Schema
+	for _, d := range s.Defs {
+		subs = append(subs, d)
+	}
+	for _, p := range s.Properties {
+		subs = append(subs, p)
+	}
+	subs = append(subs, s.AdditionalProperties, s.Items, s.Not)
+	subs = append(subs, s.AllOf...)
+	subs = append(subs, s.AnyOf...)
+	subs = append(subs, s.OneOf...)
+	for _, sub := range subs {
+		if sub == nil {
+			continue
+		}
+		if err := sub.prepare(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve finds the target of a local $ref
+func (s *Schema) resolve(ref string) (*Schema, error) {
+	if ref == "#" {
+		return s, nil
+	}
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("only local references to #/$defs are supported, got %q", ref)
+	}
+	name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+	target, ok := s.Defs[name]
+	if !ok {
+		return nil, fmt.Errorf("$ref %q points at nothing", ref)
+	}
+	return target, nil
+}
+
+// SchemaViolation is one value the schema rejected
+type SchemaViolation struct {
+	// Path is the output path of the value, e.g. users[2].role ("" for the document)
+	Path string
+	// Line and Column locate the offending property in the JSSON source; 0 when
+	// it isn't known, e.g. for keys from an included file
+	Line, Column int
+	// Problem says what's wrong, e.g. "should be integer, got string"
+	Problem string
+}
+
+// SchemaError reports every violation found in one run, the first
+// maxSchemaViolations with their source context
+type SchemaError struct {
+	Violations []SchemaViolation
+	// Omitted counts violations found past maxSchemaViolations
+	Omitted int
+	lines   []string
+}
+
+func (e *SchemaError) Error() string {
+	return strings.Join(e.lines, "\n")
+}
+
+// validateSchema checks root against t.schema
+func (t *Transpiler) validateSchema(ctx context.Context, root map[string]interface{}) error {
+	c := &schemaCheck{ctx: ctx}
+	c.check(t.schema, root, nil)
+	if c.err != nil {
+		return t.checkContext(ctx, nil)
+	}
+	if c.found == 0 {
+		return nil
+	}
+
+	return t.violationError(c, func(path []interface{}) (int, int) {
+		return t.locate(root, path)
+	}, ie.SchemaViolation)
+}
+
+// violationError builds the SchemaError for the violations c found. locate
+// finds a violation's source position and message words it.
+func (t *Transpiler) violationError(c *schemaCheck, locate func([]interface{}) (int, int), message func(path, problem string) string) error {
+	serr := &SchemaError{Omitted: c.found - len(c.violations)}
+	for _, v := range c.violations {
+		line, col := locate(v.path)
+		path := formatPath(v.path)
+		serr.Violations = append(serr.Violations, SchemaViolation{Path: path, Line: line, Column: col, Problem: v.problem})
+		if path == "" {
+			path = "the document"
+		}
+		serr.lines = append(serr.lines, t.errHead(line, col, message(path, v.problem)))
+	}
+	if serr.Omitted > 0 {
+		serr.lines = append(serr.lines, ie.MoreSchemaViolations(serr.Omitted))
+	}
+	return serr
+}
+
+// schemaCheck walks a value against a schema, collecting violations. A quick
+// check only needs to know whether there is any, for anyOf and friends.
+type schemaCheck struct {
+	ctx        context.Context
+	quick      bool
+	found      int
+	violations []violation
+	n          int
+	err        error
+}
+
+// violation is a SchemaViolation before it's been located in the source.
+// Path segments are object keys (string) and array indexes (int).
+type violation struct {
+	path    []interface{}
+	problem string
+}
+
+func (c *schemaCheck) fail(path []interface{}, format string, args ...interface{}) {
+	c.found++
+	if len(c.violations) < maxSchemaViolations {
+		c.violations = append(c.violations, violation{path: path, problem: fmt.Sprintf(format, args...)})
+	}
+}
+
+// stop reports whether checking can end early
+func (c *schemaCheck) stop() bool {
+	return c.err != nil || (c.quick && c.found > 0)
+}
+
+// passes reports whether val satisfies s, without recording violations
+func (c *schemaCheck) passes(s *Schema, val interface{}, path []interface{}) bool {
+	sub := &schemaCheck{ctx: c.ctx, quick: true}
+	sub.check(s, val, path)
+	if sub.err != nil {
+		c.err = sub.err
+	}
+	return sub.found == 0
+}
+
+func (c *schemaCheck) check(s *Schema, val interface{}, path []interface{}) {
+	if c.stop() {
+		return
+	}
+	if s.ref != nil {
+		c.check(s.ref, val, path)
+	}
+
+	got := jsonType(val)
+	if len(s.Type) > 0 && !typeAllowed(s.Type, got) {
+		c.fail(path, "should be %s, got %s", strings.Join(s.Type, " or "), got)
+		return
+	}
+	if s.Enum != nil && !enumContains(s.Enum, val) {
+		c.fail(path, "should be one of %s", formatJSONList(s.Enum))
+	}
+	if len(s.Const) > 0 && !jsonEqual(val, s.constV) {
+		c.fail(path, "should be %s", s.Const)
+	}
+
+	switch v := val.(type) {
+	case map[string]interface{}:
+		c.checkObject(s, v, path)
+	case string:
+		c.checkString(s, v, path)
+	case bool, nil:
+	default:
+		if seq, ok := asSequence(val); ok {
+			c.checkArray(s, seq, path)
+		} else if f, ok := schemaNumber(val); ok {
+			c.checkNumber(s, f, path)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		c.check(sub, val, path)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			if c.passes(sub, val, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			c.failAnyOf(s.AnyOf, got, val, path)
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if c.passes(sub, val, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			c.fail(path, "matches %d of the schemas in oneOf instead of exactly one", matched)
+		}
+	}
+	if s.Not != nil && c.passes(s.Not, val, path) {
+		if len(path) > 0 && isEmptySchema(s.Not) {
+			// A false schema, e.g. additionalProperties: false
+			c.fail(path, "is not allowed here")
+		} else {
+			c.fail(path, "matches a schema it must not")
+		}
+	}
+}
+
+// failAnyOf reports a value no anyOf alternative accepted. When exactly one
+// alternative takes the value's type, its own complaint is the useful one,
+// e.g. "should be at most 1" for float(0..1) | null.
+func (c *schemaCheck) failAnyOf(alts []*Schema, got string, val interface{}, path []interface{}) {
+	const problem = "doesn't match any of the schemas in anyOf"
+	var candidate *Schema
+	for _, alt := range alts {
+		if len(alt.Type) > 0 && !typeAllowed(alt.Type, got) {
+			continue
+		}
+		if candidate != nil {
+			c.fail(path, problem)
+			return
+		}
+		candidate = alt
+	}
+	if candidate == nil {
+		c.fail(path, problem)
+		return
+	}
+	c.check(candidate, val, path)
+}
+
+func (c *schemaCheck) checkObject(s *Schema, obj map[string]interface{}, path []interface{}) {
+	for _, key := range s.Required {
+		if _, ok := obj[key]; !ok {
+			c.fail(path, "is missing the required key %q", key)
+		}
+	}
+	// Sorted, so violations come out in the same order every run
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		if c.stop() {
+			return
+		}
+		keyPath := append(path[:len(path):len(path)], key)
+		if ps, ok := s.Properties[key]; ok {
+			c.check(ps, obj[key], keyPath)
+		} else if s.AdditionalProperties != nil {
+			c.check(s.AdditionalProperties, obj[key], keyPath)
+		}
+	}
+}
+
+func (c *schemaCheck) checkArray(s *Schema, seq sequence, path []interface{}) {
+	if s.MinItems != nil && seq.Len() < *s.MinItems {
+		c.fail(path, "should have at least %d items, has %d", *s.MinItems, seq.Len())
+	}
+	if s.MaxItems != nil && seq.Len() > *s.MaxItems {
+		c.fail(path, "should have at most %d items, has %d", *s.MaxItems, seq.Len())
+	}
+	if s.Items == nil {
+		return
+	}
+	for i := 0; i < seq.Len(); i++ {
+		if c.n++; c.n%cancelCheckEvery == 0 {
+			c.err = c.ctx.Err()
+		}
+		if c.stop() {
+			return
+		}
+		c.check(s.Items, seq.Index(i), append(path[:len(path):len(path)], i))
+	}
+}
+
+func (c *schemaCheck) checkString(s *Schema, str string, path []interface{}) {
+	n := utf8.RuneCountInString(str)
+	if s.MinLength != nil && n < *s.MinLength {
+		c.fail(path, "should be at least %d characters long, is %d", *s.MinLength, n)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		c.fail(path, "should be at most %d characters long, is %d", *s.MaxLength, n)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		c.fail(path, "should match the pattern %q", s.Pattern)
+	}
+}
+
+func (c *schemaCheck) checkNumber(s *Schema, f float64, path []interface{}) {
+	if s.Minimum != nil && f < *s.Minimum {
+		c.fail(path, "should be at least %v, got %v", *s.Minimum, f)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		c.fail(path, "should be at most %v, got %v", *s.Maximum, f)
+	}
+	if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+		c.fail(path, "should be greater than %v, got %v", *s.ExclusiveMinimum, f)
+	}
+	if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+		c.fail(path, "should be less than %v, got %v", *s.ExclusiveMaximum, f)
+	}
+}
+
+// jsonType names the JSON type of an evaluated value. Numbers with no
+// fractional part count as integers, as JSON Schema has it.
+func jsonType(val interface{}) string {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}, RangeResult:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+	case Decimal:
+		if v.rat.IsInt() {
+			return "integer"
+		}
+	}
+	return "number"
+}
+
+func typeAllowed(types SchemaTypes, got string) bool {
+	for _, typ := range types {
+		if typ == got || (typ == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaNumber converts a numeric value for range checks and comparisons
+func schemaNumber(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case Decimal:
+		f, _ := v.rat.Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+func enumContains(enum []interface{}, val interface{}) bool {
+	for _, want := range enum {
+		if jsonEqual(val, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares an evaluated value with a value decoded from the schema
+func jsonEqual(val, want interface{}) bool {
+	switch w := want.(type) {
+	case float64:
+		f, ok := schemaNumber(val)
+		return ok && f == w
+	case map[string]interface{}:
+		obj, ok := val.(map[string]interface{})
+		if !ok || len(obj) != len(w) {
+			return false
+		}
+		for k, wv := range w {
+			v, ok := obj[k]
+			if !ok || !jsonEqual(v, wv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		seq, ok := asSequence(val)
+		if !ok || seq.Len() != len(w) {
+			return false
+		}
+		for i, wv := range w {
+			if !jsonEqual(seq.Index(i), wv) {
+				return false
+			}
+		}
+		return true
+	}
+	return val == want
+}
+
+func isEmptySchema(s *Schema) bool {
+	data, err := json.Marshal(s)
+	return err == nil && string(data) == "{}"
+}
+
+func formatJSONList(vals []interface{}) string {
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatPath writes path segments as users[2].role
+func formatPath(path []interface{}) string {
+	var b strings.Builder
+	for _, seg := range path {
+		switch s := seg.(type) {
+		case string:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s)
+		case int:
+			fmt.Fprintf(&b, "[%d]", s)
+		}
+	}
+	return b.String()
+}
+
+// locate finds the source position of the value at path by walking the AST
+// alongside the evaluated document. It follows object keys, array literal
+// elements and template rows for as long as they line up with the output one
+// to one, and otherwise stops at the closest enclosing position it knows.
+func (t *Transpiler) locate(root map[string]interface{}, path []interface{}) (int, int) {
+	if len(path) == 0 {
+		return 0, 0
+	}
+	key, _ := path[0].(string)
+	var expr ast.Expression
+	line, col := 0, 0
+	for _, stmt := range t.program.Statements {
+		// The last assignment to a key is the one in the output
+		if s, ok := stmt.(*ast.AssignmentStatement); ok && s.Name.Value == key {
+			expr, line, col = s.Value, s.Token.Line, s.Token.Column
+		}
+	}
+	if expr == nil {
+		return 0, 0
+	}
+	return t.locateFrom(expr, root[key], path[1:], line, col)
+}
+
+// locateFrom is locate below a known expression: val is what expr evaluated
+// to, path is relative to it and line, col is the position to fall back on.
+func (t *Transpiler) locateFrom(expr ast.Expression, val interface{}, path []interface{}, line, col int) (int, int) {
+	for i := 0; i < len(path) && expr != nil; i++ {
+		var next ast.Expression
+		switch e := expr.(type) {
+		case *ast.ObjectLiteral:
+			k, ok := path[i].(string)
+			if !ok {
+				return line, col
+			}
+			if tok, ok := e.KeyTokens[k]; ok {
+				line, col = tok.Line, tok.Column
+			}
+			next = e.Properties[k]
+		case *ast.ArrayLiteral:
+			idx, ok := path[i].(int)
+			if !ok || seqLen(val) != len(e.Elements) {
+				return line, col
+			}
+			next = e.Elements[idx]
+			line, col = positionOr(next, line, col)
+		case *ast.ArrayTemplate:
+			idx, ok := path[i].(int)
+			if !ok || seqLen(val) != len(e.Rows) || len(e.Rows[idx]) == 0 {
+				return line, col
+			}
+			row := e.Rows[idx]
+			line, col = positionOr(row[0], line, col)
+			// Without a map clause each output key comes from its template column
+			if e.Map != nil || i+1 >= len(path) {
+				return line, col
+			}
+			k, _ := path[i+1].(string)
+			column := -1
+			for c, tk := range e.Template.Keys {
+				if tk == k {
+					column = c
+				}
+			}
+			if column < 0 || column >= len(row) {
+				return line, col
+			}
+			val = pathChild(val, path[i])
+			i++
+			next = row[column]
+			line, col = positionOr(next, line, col)
+		default:
+			return line, col
+		}
+		expr = next
+		val = pathChild(val, path[i])
+	}
+	return line, col
+}
+
+// positionOr returns node's position, or line and col if it has none
+func positionOr(node ast.Node, line, col int) (int, int) {
+	if l, c := nodePosition(node); l > 0 {
+		return l, c
+	}
+	return line, col
+}
+
+// pathChild steps into val by one path segment
+func pathChild(val interface{}, seg interface{}) interface{} {
+	switch s := seg.(type) {
+	case string:
+		obj, _ := val.(map[string]interface{})
+		return obj[s]
+	case int:
+		if seq, ok := asSequence(val); ok && s < seq.Len() {
+			return seq.Index(s)
+		}
+	}
+	return nil
+}
+
+func seqLen(val interface{}) int {
+	if seq, ok := asSequence(val); ok {
+		return seq.Len()
+	}
+	return -1
+}
@@ -0,0 +1,168 @@
+package transpiler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// withSourceFile is a newTestTranspiler option that names the source file, so
+// errors carry file:line:col positions
+func withSourceFile(name string) func(*Transpiler) {
+	return func(tr *Transpiler) { tr.sourceFile = name }
+}
+
+func validateAgainst(t *testing.T, input, schema string) error {
+	t.Helper()
+	s, err := ParseSchema([]byte(schema))
+	if err != nil {
+		t.Fatalf("ParseSchema error: %v", err)
+	}
+	_, err = newTestTranspiler(t, input, withSourceFile("main.jsson"), func(tr *Transpiler) { tr.SetSchema(s) }).Transpile()
+	return err
+}
+
+func TestSchema_ViolationsPointAtSource(t *testing.T) {
+	input := `name = "api"
+replicas = "three"
+server {
+  host = "localhost"
+  port = 99999
+}
+users [
+  template { id, role }
+  1, "admin"
+  2, "root"
+]`
+	schema := `{
+  "type": "object",
+  "required": ["name", "env"],
+  "properties": {
+    "replicas": {"type": "integer"},
+    "server": {
+      "additionalProperties": false,
+      "properties": {"port": {"$ref": "#/$defs/port"}}
+    },
+    "users": {"items": {"properties": {"role": {"enum": ["admin", "user"]}}}}
+  },
+  "$defs": {"port": {"type": "integer", "minimum": 1, "maximum": 65535}}
+}`
+
+	err := validateAgainst(t, input, schema)
+	var serr *SchemaError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Expected a SchemaError, got %v", err)
+	}
+
+	want := []struct {
+		path    string
+		line    int
+		problem string
+	}{
+		{"", 0, `missing the required key "env"`},
+		{"replicas", 2, "should be integer, got string"},
+		{"server.host", 4, "not allowed here"},
+		{"server.port", 5, "at most 65535"},
+		{"users[1].role", 10, `one of "admin", "user"`},
+	}
+	if len(serr.Violations) != len(want) {
+		t.Fatalf("Expected %d violations, got %+v", len(want), serr.Violations)
+	}
+	for i, w := range want {
+		v := serr.Violations[i]
+		if v.Path != w.path || v.Line != w.line || !strings.Contains(v.Problem, w.problem) {
+			t.Errorf("Violation %d: expected %s on line %d (%s), got %+v", i, w.path, w.line, w.problem, v)
+		}
+	}
+	if !strings.Contains(err.Error(), "main.jsson:10:") {
+		t.Errorf("Expected the message to carry the source position, got %v", err)
+	}
+}
+
+func TestSchema_ValidDocumentPasses(t *testing.T) {
+	input := "port = 8080\nratio = 2.0\nhosts = [ \"a\", \"b\" ]\nids = 1..3\nmode = \"prod\""
+	schema := `{
+  "properties": {
+    "port": {"type": "integer", "exclusiveMinimum": 0},
+    "ratio": {"type": "integer"},
+    "hosts": {"type": "array", "minItems": 1, "items": {"type": "string", "pattern": "^[a-z]+$"}},
+    "ids": {"type": "array", "maxItems": 3, "items": {"type": "integer"}},
+    "mode": {"anyOf": [{"const": "dev"}, {"const": "prod"}]}
+  }
+}`
+	if err := validateAgainst(t, input, schema); err != nil {
+		t.Fatalf("Expected the document to pass, got %v", err)
+	}
+}
+
+func TestSchema_Combinators(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"oneOf", `{"properties": {"x": {"oneOf": [{"type": "integer"}, {"minimum": 0}]}}}`, "matches 2 of the schemas in oneOf"},
+		{"not", `{"properties": {"x": {"not": {"type": "integer"}}}}`, "matches a schema it must not"},
+		{"allOf", `{"properties": {"x": {"allOf": [{"minimum": 0}, {"maximum": 3}]}}}`, "should be at most 3, got 5"},
+		{"false schema", `{"properties": {"x": false}}`, "x is not allowed here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAgainst(t, "x = 5", tt.schema); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSchema_TooManyViolationsAreSummarised(t *testing.T) {
+	err := validateAgainst(t, "ids = 0..99", `{"properties": {"ids": {"items": {"type": "string"}}}}`)
+	var serr *SchemaError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Expected a SchemaError, got %v", err)
+	}
+	if len(serr.Violations) != maxSchemaViolations || serr.Omitted != 100-maxSchemaViolations {
+		t.Errorf("Expected %d reported and %d omitted, got %d and %d", maxSchemaViolations, 100-maxSchemaViolations, len(serr.Violations), serr.Omitted)
+	}
+	if !strings.Contains(err.Error(), "and 80 more") {
+		t.Errorf("Expected a summary of the omitted violations, got %v", err)
+	}
+}
+
+func TestParseSchema_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"not json", `{"type": `, "doesn't make sense"},
+		{"unknown type", `{"type": "int"}`, `unknown type "int"`},
+		{"remote ref", `{"$ref": "https://example.com/s.json"}`, "only local references"},
+		{"missing def", `{"$ref": "#/$defs/nope"}`, "points at nothing"},
+		{"bad pattern", `{"pattern": "("}`, "pattern"},
+		{"unsupported keywords", `{"uniqueItems": true, "multipleOf": 5, "title": "ok"}`, `unsupported keywords "multipleOf", "uniqueItems" —`},
+		{"nested unsupported keyword", `{"properties": {"tags": {"type": "array", "prefixItems": []}}}`, `"prefixItems"`},
+		{"format", `{"$defs": {"host": {"format": "hostname"}}}`, `"format"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchema([]byte(tt.schema)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSchema_InferredSchemaValidatesItsSource(t *testing.T) {
+	input := "users [\n template { id, role }\n 1, \"admin\"\n 2, \"user\"\n 3, \"user\"\n]\nports = 80..82"
+	out, err := newTestTranspiler(t, input).TranspileToJSONSchema()
+	if err != nil {
+		t.Fatalf("TranspileToJSONSchema error: %v", err)
+	}
+	if err := validateAgainst(t, input, string(out)); err != nil {
+		t.Fatalf("A document should satisfy its own inferred schema, got %v", err)
+	}
+	if err := validateAgainst(t, strings.Replace(input, `"user"`, `"guest"`, 1), string(out)); err == nil {
+		t.Fatal("Expected a role outside the inferred enum to be rejected")
+	}
+}
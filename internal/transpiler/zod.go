@@ -0,0 +1,176 @@
+package transpiler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// validatorLib spells schemas for one TypeScript runtime validation library.
+// The format strings take the inner schema, or the joined members of a union
+// or enum.
+type validatorLib struct {
+	imports  string
+	infer    string // the type a schema validates; %s is the schema's const
+	str      string
+	integer  string
+	number   string
+	boolean  string
+	null     string
+	unknown  string
+	array    string
+	union    string
+	object   string // %s is the property list
+	literal  string
+	enum     string
+	nullable string
+	optional string
+}
+
+var zodLib = validatorLib{
+	imports:  `import { z } from "zod";`,
+	infer:    "z.infer<typeof %s>",
+	str:      "z.string()",
+	integer:  "z.number().int()",
+	number:   "z.number()",
+	boolean:  "z.boolean()",
+	null:     "z.null()",
+	unknown:  "z.unknown()",
+	array:    "z.array(%s)",
+	union:    "z.union([%s])",
+	object:   "z.object({\n%s})",
+	literal:  "z.literal(%s)",
+	enum:     "z.enum([%s])",
+	nullable: "%s.nullable()",
+	optional: "%s.optional()",
+}
+
+var valibotLib = validatorLib{
+	imports:  `import * as v from "valibot";`,
+	infer:    "v.InferOutput<typeof %s>",
+	str:      "v.string()",
+	integer:  "v.pipe(v.number(), v.integer())",
+	number:   "v.number()",
+	boolean:  "v.boolean()",
+	null:     "v.null()",
+	unknown:  "v.unknown()",
+	array:    "v.array(%s)",
+	union:    "v.union([%s])",
+	object:   "v.object({\n%s})",
+	literal:  "v.literal(%s)",
+	enum:     "v.picklist([%s])",
+	nullable: "v.nullable(%s)",
+	optional: "v.optional(%s)",
+}
+
+// TranspileToZod writes a Zod schema inferred from the evaluated document,
+// with a TypeScript type for each object, so runtime checks stay in sync with
+// the source
+func (t *Transpiler) TranspileToZod() ([]byte, error) {
+	return t.TranspileToZodContext(context.Background())
+}
+
+// TranspileToZodContext is TranspileToZod that stops with ctx's error once
+// ctx is done
+func (t *Transpiler) TranspileToZodContext(ctx context.Context) ([]byte, error) {
+	return t.transpileToValidator(ctx, &zodLib)
+}
+
+// TranspileToValibot is TranspileToZod for the Valibot library
+func (t *Transpiler) TranspileToValibot() ([]byte, error) {
+	return t.TranspileToValibotContext(context.Background())
+}
+
+// TranspileToValibotContext is TranspileToValibot that stops with ctx's error
+// once ctx is done
+func (t *Transpiler) TranspileToValibotContext(ctx context.Context) ([]byte, error) {
+	return t.transpileToValidator(ctx, &valibotLib)
+}
+
+func (t *Transpiler) transpileToValidator(ctx context.Context, lib *validatorLib) ([]byte, error) {
+	ctx, stop := t.begin(ctx)
+	defer stop()
+	root, err := t.evalDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := t.inferTypeModel(ctx, root)
+	if err != nil {
+		return t.limitOutput(ctx, nil, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(lib.imports + "\n")
+	// A const can't be used before it's declared; the model lists the
+	// records a type uses before that type
+	for _, r := range m.types {
+		var props bytes.Buffer
+		for _, f := range r.fields() {
+			writeJSDoc(&props, f.doc, "  ")
+			schema := m.validatorSchema(lib, f.shape)
+			if f.optional {
+				schema = fmt.Sprintf(lib.optional, schema)
+			}
+			props.WriteString(fmt.Sprintf("  %s: %s,\n", tsPropertyName(f.key), schema))
+		}
+		buf.WriteString(fmt.Sprintf("\nexport const %sSchema = "+lib.object+";\n", r.name, props.String()))
+		buf.WriteString(fmt.Sprintf("export type %s = "+lib.infer+";\n", r.name, r.name+"Schema"))
+	}
+	return t.limitOutput(ctx, buf.Bytes(), nil)
+}
+
+// validatorSchema spells the schema of a position: a union of everything
+// seen there, with small repeated string sets as literals or an enum
+func (m *typeModel) validatorSchema(lib *validatorLib, s *shape) string {
+	types, nullable := s.valueTypes()
+	var parts []string
+	for _, typ := range types {
+		switch typ {
+		case "object":
+			parts = append(parts, m.names[s]+"Schema")
+		case "array":
+			elem := lib.unknown
+			if s.items != nil {
+				elem = m.validatorSchema(lib, s.items)
+			}
+			parts = append(parts, fmt.Sprintf(lib.array, elem))
+		case "string":
+			switch values := s.enumValues(); len(values) {
+			case 0:
+				parts = append(parts, lib.str)
+			case 1:
+				parts = append(parts, fmt.Sprintf(lib.literal, quoteTypeScriptString(values[0])))
+			default:
+				quoted := make([]string, len(values))
+				for i, v := range values {
+					quoted[i] = quoteTypeScriptString(v)
+				}
+				parts = append(parts, fmt.Sprintf(lib.enum, strings.Join(quoted, ", ")))
+			}
+		case "integer":
+			parts = append(parts, lib.integer)
+		case "number":
+			parts = append(parts, lib.number)
+		case "boolean":
+			parts = append(parts, lib.boolean)
+		}
+	}
+
+	var schema string
+	switch len(parts) {
+	case 0:
+		if nullable {
+			return lib.null
+		}
+		return lib.unknown
+	case 1:
+		schema = parts[0]
+	default:
+		schema = fmt.Sprintf(lib.union, strings.Join(parts, ", "))
+	}
+	if nullable {
+		schema = fmt.Sprintf(lib.nullable, schema)
+	}
+	return schema
+}
@@ -0,0 +1,55 @@
+package transpiler
+
+import (
+	"strings"
+	"testing"
+)
+
+const validatorInput = `/// Service name
+name = "api"
+users = [
+  { id = 1, role = "admin", email = "a@x.io" }
+  { id = 2, role = "user" }
+  { id = 3, role = "user", email = null, tags = [ "x", 1 ] }
+]
+version = [ "v1", "v1" ]
+nothing = null`
+
+func TestZod_SchemaFromValues(t *testing.T) {
+	out := generate(t, validatorInput, nil, nil, (*Transpiler).TranspileToZod)
+
+	for _, want := range []string{
+		"import { z } from \"zod\";\n",
+		"export const UserSchema = z.object({\n  email: z.string().nullable().optional(),\n  id: z.number().int(),\n  role: z.enum([\"admin\", \"user\"]),\n  tags: z.array(z.union([z.number().int(), z.string()])).optional(),\n});\nexport type User = z.infer<typeof UserSchema>;\n",
+		"  /** Service name */\n  name: z.string(),\n",
+		"  nothing: z.null(),\n",
+		"  users: z.array(UserSchema),\n",
+		"  version: z.array(z.literal(\"v1\")),\n",
+		"export type Config = z.infer<typeof ConfigSchema>;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	// Consts can't be used before they're declared
+	if strings.Index(out, "const UserSchema") > strings.Index(out, "const ConfigSchema") {
+		t.Errorf("Expected UserSchema to be declared before it's used:\n%s", out)
+	}
+}
+
+func TestValibot_SchemaFromValues(t *testing.T) {
+	out := generate(t, validatorInput, nil, nil, (*Transpiler).TranspileToValibot)
+
+	for _, want := range []string{
+		"import * as v from \"valibot\";\n",
+		"  email: v.optional(v.nullable(v.string())),\n",
+		"  id: v.pipe(v.number(), v.integer()),\n",
+		"  role: v.picklist([\"admin\", \"user\"]),\n",
+		"  version: v.array(v.literal(\"v1\")),\n",
+		"export type Config = v.InferOutput<typeof ConfigSchema>;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}